@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"library-api-category/internal/config"
+	"library-api-category/internal/repositories"
+	"library-api-category/internal/services"
+	"library-api-category/pkg/database"
+	"log"
+	"os"
+)
+
+// reconcile-counts compares the materialized per-category book counts with
+// book_categories, prints every drift and recomputes the counts.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report drift, do not fix it")
+	failOnDrift := flag.Bool("fail-on-drift", false, "exit with status 2 when drift is found")
+	flag.Parse()
+
+	config.LoadConfig()
+	psqlDB, err := database.NewPqSQLClient()
+	if err != nil {
+		log.Fatal("Could not connect to PqSQL:", err)
+	}
+	defer psqlDB.Close()
+
+	statsService := services.NewCategoryStatsService(psqlDB, repositories.NewCategoryStatsRepository())
+	result, custErr := statsService.ReconcileBookCounts(context.Background(), *dryRun)
+	if custErr != nil {
+		log.Fatal("Could not reconcile book counts: ", custErr.Message)
+	}
+
+	for _, drift := range result.Drift {
+		fmt.Printf("category %d: stored %d, actual %d\n", drift.CategoryID, drift.StoredCount, drift.ActualCount)
+	}
+
+	if result.DryRun {
+		fmt.Printf("%d categories drifted, nothing changed (dry run)\n", len(result.Drift))
+	} else {
+		fmt.Printf("%d categories drifted, %d counts recomputed\n", len(result.Drift), result.Fixed)
+	}
+
+	if *failOnDrift && len(result.Drift) > 0 {
+		os.Exit(2)
+	}
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"library-api-category/internal/config"
 	"library-api-category/internal/factory"
-	"library-api-category/internal/grpc/client"
 	"library-api-category/internal/routes"
 	"library-api-category/pkg/database"
+	pb "library-api-category/proto/category"
 	"log"
+	"net"
 	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -20,24 +26,81 @@ func main() {
 	provider := factory.InitFactory(psqlDB)
 
 	var wg sync.WaitGroup
-	wg.Add(1)
+	wg.Add(5)
 
 	go func() {
 		defer wg.Done()
 		runHTTPServer(provider)
 	}()
 
+	go func() {
+		defer wg.Done()
+		runGRPCServer(provider)
+	}()
+
+	go func() {
+		defer wg.Done()
+		provider.OutboxRelay.Run(context.Background())
+	}()
+
+	go func() {
+		defer wg.Done()
+		provider.WebhookDispatcher.Run(context.Background())
+	}()
+
+	go func() {
+		defer wg.Done()
+		provider.EventListener.Run(context.Background())
+	}()
+
+	if provider.CacheInvalidation != nil {
+		go provider.CacheInvalidation.Run(context.Background())
+	}
+
+	if provider.TokenRevocations != nil {
+		go provider.TokenRevocations.Run(context.Background())
+	}
+
+	go provider.Policy.Watch(context.Background(), config.ENV.AuthPolicyReload)
+
+	if provider.RateLimitPruner != nil {
+		go provider.RateLimitPruner.Run(context.Background(), 10*time.Minute)
+	}
+
+	for _, reloader := range provider.TLSReloaders {
+		go reloader.Watch(context.Background(), config.ENV.TLSReloadInterval)
+	}
+
+	if provider.OrphanReconciler != nil {
+		go provider.OrphanReconciler.Run(context.Background())
+	}
+
 	wg.Wait()
 }
 
 func runHTTPServer(provider *factory.Provider) {
-	authClient, err := client.NewAuthClient(config.ENV.UserGRPC)
+	router := routes.RegisterRoutes(provider, provider.TokenValidator)
+	log.Printf("REST API server running on port %s\n", config.ENV.ServerPort)
+	log.Fatal(router.Run(":" + config.ENV.ServerPort))
+}
+
+func runGRPCServer(provider *factory.Provider) {
+	listener, err := net.Listen("tcp", ":"+config.ENV.GRPCPort)
 	if err != nil {
-		log.Fatalf("Failed to initialize auth client: %v", err)
+		log.Fatalf("Failed to listen on gRPC port: %v", err)
 	}
-	defer authClient.Close()
 
-	router := routes.RegisterRoutes(provider, authClient)
-	log.Printf("REST API server running on port %s\n", config.ENV.ServerPort)
-	log.Fatal(router.Run(":" + config.ENV.ServerPort))
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(provider.GRPCAuth.UnaryInterceptor()),
+		grpc.StreamInterceptor(provider.GRPCAuth.StreamInterceptor()),
+	}
+	if provider.ServerTLS != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(provider.ServerTLS.ServerConfig())))
+	}
+
+	grpcServer := grpc.NewServer(options...)
+	pb.RegisterCategoryServiceServer(grpcServer, provider.CategoryServer)
+
+	log.Printf("gRPC server running on port %s\n", config.ENV.GRPCPort)
+	log.Fatal(grpcServer.Serve(listener))
 }
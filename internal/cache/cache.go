@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+)
+
+type Stats struct {
+	Backend   string `json:"backend"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+}
+
+// StatsReporter is implemented by components exposing statistics per cache name.
+type StatsReporter interface {
+	CacheStats() map[string]Stats
+}
+
+// Cache stores serialized values with a backend wide TTL. Implementations
+// must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every key starting with prefix, an empty prefix clears the cache.
+	DeletePrefix(ctx context.Context, prefix string) error
+	Stats() Stats
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// GenerationalCache counts the invalidations of the Cache it wraps. A value
+// read from the database is stored with SetIfGeneration only when nothing was
+// invalidated since the read started, so a read that raced a write cannot
+// cache what it saw before the write committed. Every invalidation, local or
+// from the InvalidationListener, must go through the same GenerationalCache.
+type GenerationalCache struct {
+	Cache
+	mu         sync.RWMutex
+	generation uint64
+}
+
+func NewGenerationalCache(cache Cache) *GenerationalCache {
+	return &GenerationalCache{Cache: cache}
+}
+
+// Generation returns the number of invalidations so far, to be taken before
+// reading the value to cache.
+func (cache *GenerationalCache) Generation() uint64 {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return cache.generation
+}
+
+// SetIfGeneration stores value under key unless an invalidation happened
+// after generation was taken, and reports whether it did.
+func (cache *GenerationalCache) SetIfGeneration(ctx context.Context, generation uint64, key string, value []byte) (bool, error) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	if cache.generation != generation {
+		return false, nil
+	}
+	return true, cache.Cache.Set(ctx, key, value)
+}
+
+func (cache *GenerationalCache) Delete(ctx context.Context, key string) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.generation++
+	return cache.Cache.Delete(ctx, key)
+}
+
+func (cache *GenerationalCache) DeletePrefix(ctx context.Context, prefix string) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.generation++
+	return cache.Cache.DeletePrefix(ctx, prefix)
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// InvalidationChannel is the Postgres NOTIFY channel carrying cache keys to
+// drop. A payload ending in "*" drops every key with that prefix.
+const InvalidationChannel = "cache_invalidations"
+
+// InvalidationListener applies invalidations sent by any replica to the local
+// cache once the sending transaction commits.
+type InvalidationListener struct {
+	DSN   string
+	Cache Cache
+}
+
+func NewInvalidationListener(dsn string, cache Cache) *InvalidationListener {
+	return &InvalidationListener{
+		DSN:   dsn,
+		Cache: cache,
+	}
+}
+
+func (listener *InvalidationListener) Run(ctx context.Context) {
+	pqListener := pq.NewListener(listener.DSN, time.Second, 30*time.Second, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("cache invalidation listener: %v", err)
+		}
+	})
+	defer pqListener.Close()
+
+	err := pqListener.Listen(InvalidationChannel)
+	if err != nil {
+		log.Printf("cache invalidation listener: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-pqListener.Notify:
+			err = listener.apply(ctx, notification)
+			if err != nil {
+				log.Printf("cache invalidation listener: %v", err)
+			}
+		case <-time.After(90 * time.Second):
+			go pqListener.Ping()
+		}
+	}
+}
+
+// apply drops the keys named by notification.
+func (listener *InvalidationListener) apply(ctx context.Context, notification *pq.Notification) error {
+	if notification == nil {
+		// reconnected, invalidations may have been missed
+		return listener.Cache.DeletePrefix(ctx, "")
+	}
+	if prefix, ok := strings.CutSuffix(notification.Extra, "*"); ok {
+		return listener.Cache.DeletePrefix(ctx, prefix)
+	}
+	return listener.Cache.Delete(ctx, notification.Extra)
+}
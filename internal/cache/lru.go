@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size bounded least-recently-used cache whose entries also expire
+// after a fixed TTL. It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	ll         *list.List
+	items      map[K]*list.Element
+	stats      Stats
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func NewLRU[K comparable, V any](maxEntries int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      make(map[K]*list.Element),
+	}
+}
+
+func (lru *LRU[K, V]) Get(key K) (V, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if elem, ok := lru.items[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		if time.Now().Before(entry.expiresAt) {
+			lru.ll.MoveToFront(elem)
+			lru.stats.Hits++
+			return entry.value, true
+		}
+		lru.removeElement(elem)
+	}
+
+	lru.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+func (lru *LRU[K, V]) Set(key K, value V) {
+	lru.SetWithTTL(key, value, lru.ttl)
+}
+
+// SetWithTTL stores value with its own ttl instead of the cache wide one.
+func (lru *LRU[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := lru.items[key]; ok {
+		lru.ll.MoveToFront(elem)
+		entry := elem.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		return
+	}
+
+	lru.items[key] = lru.ll.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for lru.ll.Len() > lru.maxEntries {
+		lru.removeElement(lru.ll.Back())
+		lru.stats.Evictions++
+	}
+}
+
+func (lru *LRU[K, V]) Delete(key K) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if elem, ok := lru.items[key]; ok {
+		lru.removeElement(elem)
+	}
+}
+
+// DeleteFunc removes every entry whose key matches.
+func (lru *LRU[K, V]) DeleteFunc(match func(key K) bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	for key, elem := range lru.items {
+		if match(key) {
+			lru.removeElement(elem)
+		}
+	}
+}
+
+// DeleteWhere removes every entry whose key and value match.
+func (lru *LRU[K, V]) DeleteWhere(match func(key K, value V) bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	for key, elem := range lru.items {
+		if match(key, elem.Value.(*lruEntry[K, V]).value) {
+			lru.removeElement(elem)
+		}
+	}
+}
+
+// Purge removes every entry, statistics are kept.
+func (lru *LRU[K, V]) Purge() {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	lru.ll.Init()
+	lru.items = make(map[K]*list.Element)
+}
+
+func (lru *LRU[K, V]) Stats() Stats {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	stats := lru.stats
+	stats.Size = lru.ll.Len()
+	stats.Capacity = lru.maxEntries
+	return stats
+}
+
+func (lru *LRU[K, V]) removeElement(elem *list.Element) {
+	lru.ll.Remove(elem)
+	delete(lru.items, elem.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// MemoryCache keeps values in a per-process LRU. Other replicas learn about
+// changes through the InvalidationListener.
+type MemoryCache struct {
+	lru *LRU[string, []byte]
+}
+
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		lru: NewLRU[string, []byte](maxEntries, ttl),
+	}
+}
+
+func (cache *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := cache.lru.Get(key)
+	return value, ok, nil
+}
+
+func (cache *MemoryCache) Set(ctx context.Context, key string, value []byte) error {
+	cache.lru.Set(key, value)
+	return nil
+}
+
+func (cache *MemoryCache) Delete(ctx context.Context, key string) error {
+	cache.lru.Delete(key)
+	return nil
+}
+
+func (cache *MemoryCache) DeletePrefix(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		cache.lru.Purge()
+		return nil
+	}
+	cache.lru.DeleteFunc(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+	return nil
+}
+
+func (cache *MemoryCache) Stats() Stats {
+	stats := cache.lru.Stats()
+	stats.Backend = "memory"
+	return stats
+}
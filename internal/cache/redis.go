@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores values in any server speaking the Redis protocol, shared
+// by all replicas. Keys are namespaced so DeletePrefix never touches keys
+// owned by other applications.
+type RedisCache struct {
+	client    *redis.Client
+	ttl       time.Duration
+	namespace string
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+}
+
+func NewRedisCache(addr string, password string, db int, ttl time.Duration) *RedisCache {
+	return &RedisCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+			DB:       db,
+		}),
+		ttl:       ttl,
+		namespace: "library-api-category:",
+	}
+}
+
+func (cache *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := cache.client.Get(ctx, cache.namespace+key).Bytes()
+	if err == redis.Nil {
+		cache.misses.Add(1)
+		return nil, false, nil
+	}
+	if err != nil {
+		cache.misses.Add(1)
+		return nil, false, err
+	}
+
+	cache.hits.Add(1)
+	return value, true, nil
+}
+
+func (cache *RedisCache) Set(ctx context.Context, key string, value []byte) error {
+	return cache.client.Set(ctx, cache.namespace+key, value, cache.ttl).Err()
+}
+
+func (cache *RedisCache) Delete(ctx context.Context, key string) error {
+	return cache.client.Del(ctx, cache.namespace+key).Err()
+}
+
+func (cache *RedisCache) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := cache.client.Scan(ctx, 0, cache.namespace+prefix+"*", 500).Iterator()
+
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			err := cache.client.Del(ctx, keys...).Err()
+			if err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		return cache.client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
+
+func (cache *RedisCache) Stats() Stats {
+	return Stats{
+		Backend: "redis",
+		Hits:    cache.hits.Load(),
+		Misses:  cache.misses.Load(),
+	}
+}
+
+func (cache *RedisCache) Close() error {
+	return cache.client.Close()
+}
@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lib/pq"
+)
+
+const testNamespace = "library-api-category:"
+
+func newTestRedisCache(t *testing.T, ttl time.Duration) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	cache := NewRedisCache(server.Addr(), "", 0, ttl)
+	t.Cleanup(func() { cache.Close() })
+	return cache, server
+}
+
+func TestRedisCacheGetSet(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, time.Minute)
+
+	_, ok, err := cache.Get(ctx, "category:1")
+	if err != nil || ok {
+		t.Fatalf("Get on empty cache = ok %v, err %v, want a miss", ok, err)
+	}
+
+	err = cache.Set(ctx, "category:1", []byte(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	value, ok, err := cache.Get(ctx, "category:1")
+	if err != nil || !ok {
+		t.Fatalf("Get after Set = ok %v, err %v, want a hit", ok, err)
+	}
+	if string(value) != `{"id":1}` {
+		t.Errorf("Get = %s, want {\"id\":1}", value)
+	}
+
+	if !server.Exists(testNamespace + "category:1") {
+		t.Errorf("key is not stored under the %q namespace, keys: %v", testNamespace, server.Keys())
+	}
+
+	stats := cache.Stats()
+	if stats.Backend != "redis" || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Stats = %+v, want backend redis with 1 hit and 1 miss", stats)
+	}
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, 30*time.Second)
+
+	err := cache.Set(ctx, "category:1", []byte("1"))
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := server.TTL(testNamespace + "category:1"); ttl != 30*time.Second {
+		t.Errorf("TTL = %v, want 30s", ttl)
+	}
+
+	server.FastForward(29 * time.Second)
+	if _, ok, _ := cache.Get(ctx, "category:1"); !ok {
+		t.Errorf("key expired before its TTL")
+	}
+
+	server.FastForward(2 * time.Second)
+	if _, ok, _ := cache.Get(ctx, "category:1"); ok {
+		t.Errorf("key is still cached after its TTL")
+	}
+}
+
+func TestRedisCacheDelete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		delete func(cache *RedisCache) error
+		kept   []string
+	}{
+		{
+			name:   "single key",
+			delete: func(cache *RedisCache) error { return cache.Delete(ctx, "category:1") },
+			kept:   []string{"category:2", "book_categories:1", "book_categories:2"},
+		},
+		{
+			name:   "prefix",
+			delete: func(cache *RedisCache) error { return cache.DeletePrefix(ctx, "book_categories:") },
+			kept:   []string{"category:1", "category:2"},
+		},
+		{
+			name:   "everything",
+			delete: func(cache *RedisCache) error { return cache.DeletePrefix(ctx, "") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, server := newTestRedisCache(t, time.Minute)
+			server.Set("other-app:category:1", "x")
+			for _, key := range []string{"category:1", "category:2", "book_categories:1", "book_categories:2"} {
+				err := cache.Set(ctx, key, []byte(key))
+				if err != nil {
+					t.Fatalf("Set(%s): %v", key, err)
+				}
+			}
+
+			err := tt.delete(cache)
+			if err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+
+			want := map[string]bool{"other-app:category:1": true}
+			for _, key := range tt.kept {
+				want[testNamespace+key] = true
+			}
+			keys := server.Keys()
+			if len(keys) != len(want) {
+				t.Fatalf("keys left = %v, want %v", keys, want)
+			}
+			for _, key := range keys {
+				if !want[key] {
+					t.Errorf("key %s should have been deleted", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisCacheDeletePrefixInBatches(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, time.Minute)
+
+	for i := 0; i < 1200; i++ {
+		err := cache.Set(ctx, fmt.Sprintf("book_categories:%d", i), []byte("[]"))
+		if err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	err := cache.DeletePrefix(ctx, "book_categories:")
+	if err != nil {
+		t.Fatalf("DeletePrefix: %v", err)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("%d keys left after DeletePrefix", len(keys))
+	}
+}
+
+func TestRedisCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		notification *pq.Notification
+		kept         []string
+	}{
+		{
+			name:         "key",
+			notification: &pq.Notification{Extra: "category:1"},
+			kept:         []string{"book_categories:1"},
+		},
+		{
+			name:         "prefix",
+			notification: &pq.Notification{Extra: "book_categories:*"},
+			kept:         []string{"category:1"},
+		},
+		{
+			name:         "reconnect",
+			notification: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, _ := newTestRedisCache(t, time.Minute)
+			listener := NewInvalidationListener("", cache)
+			for _, key := range []string{"category:1", "book_categories:1"} {
+				err := cache.Set(ctx, key, []byte(key))
+				if err != nil {
+					t.Fatalf("Set(%s): %v", key, err)
+				}
+			}
+
+			err := listener.apply(ctx, tt.notification)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+
+			kept := map[string]bool{}
+			for _, key := range tt.kept {
+				kept[key] = true
+			}
+			for _, key := range []string{"category:1", "book_categories:1"} {
+				_, ok, err := cache.Get(ctx, key)
+				if err != nil {
+					t.Fatalf("Get(%s): %v", key, err)
+				}
+				if ok != kept[key] {
+					t.Errorf("Get(%s) cached = %v, want %v", key, ok, kept[key])
+				}
+			}
+		})
+	}
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, time.Minute)
+
+	err := cache.Set(ctx, "category:1", []byte("1"))
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	addr := server.Addr()
+	server.Close()
+
+	_, ok, err := cache.Get(ctx, "category:1")
+	if err == nil || ok {
+		t.Errorf("Get with Redis down = ok %v, err %v, want an error", ok, err)
+	}
+	if err := cache.Set(ctx, "category:2", []byte("2")); err == nil {
+		t.Errorf("Set with Redis down succeeded")
+	}
+	if err := cache.Delete(ctx, "category:1"); err == nil {
+		t.Errorf("Delete with Redis down succeeded")
+	}
+	if misses := cache.Stats().Misses; misses != 1 {
+		t.Errorf("Misses = %d, want the failed Get counted as a miss", misses)
+	}
+
+	err = server.StartAddr(addr)
+	if err != nil {
+		t.Fatalf("StartAddr: %v", err)
+	}
+
+	// the client redials failed connections in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err = cache.Set(ctx, "category:2", []byte("2"))
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Set after Redis came back: %v", err)
+	}
+	if _, ok, err := cache.Get(ctx, "category:2"); err != nil || !ok {
+		t.Errorf("Get after Redis came back = ok %v, err %v, want a hit", ok, err)
+	}
+}
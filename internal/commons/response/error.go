@@ -37,6 +37,30 @@ var (
 		Status:     false,
 		Message:    "UNAUTHORIZED",
 	}
+	resourceNotFoundError = CustomError{
+		Code:       "ERR0006",
+		StatusCode: http.StatusNotFound,
+		Status:     false,
+		Message:    "NOT FOUND ERROR",
+	}
+	serviceUnavailableError = CustomError{
+		Code:       "ERR0007",
+		StatusCode: http.StatusServiceUnavailable,
+		Status:     false,
+		Message:    "SERVICE UNAVAILABLE",
+	}
+	forbiddenError = CustomError{
+		Code:       "ERR0008",
+		StatusCode: http.StatusForbidden,
+		Status:     false,
+		Message:    "FORBIDDEN",
+	}
+	tooManyRequestsError = CustomError{
+		Code:       "ERR0009",
+		StatusCode: http.StatusTooManyRequests,
+		Status:     false,
+		Message:    "TOO MANY REQUESTS",
+	}
 	badRequestError = CustomError{
 		Code:       "ERR0005",
 		StatusCode: http.StatusBadRequest,
@@ -94,6 +118,33 @@ func NotFoundErrorWithAdditionalInfo(info interface{}, message ...string) *Custo
 	}
 	return &err
 }
+
+// ResourceNotFoundError is NotFoundError with a 404 status, for resources that
+// live in other services.
+func ResourceNotFoundError(message ...string) *CustomError {
+	err := resourceNotFoundError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func ServiceUnavailableError(message ...string) *CustomError {
+	err := serviceUnavailableError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func TooManyRequestsError(message ...string) *CustomError {
+	err := tooManyRequestsError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
 func UnauthorizedError(message ...string) *CustomError {
 	err := unauthorizedError
 	if len(message) != 0 {
@@ -111,6 +162,23 @@ func UnauthorizedErrorWithAdditionalInfo(info interface{}, message ...string) *C
 	return &err
 }
 
+func ForbiddenError(message ...string) *CustomError {
+	err := forbiddenError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func ForbiddenErrorWithAdditionalInfo(info interface{}, message ...string) *CustomError {
+	err := forbiddenError
+	err.AdditionalInfo = info
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
 func BadRequestError(message ...string) *CustomError {
 	err := badRequestError
 	if len(message) != 0 {
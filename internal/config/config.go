@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -11,7 +13,100 @@ type Config struct {
 	DBName         string `mapstructure:"DB_DATABASE"`
 	DBPort         string `mapstructure:"DB_PORT"`
 	ServerPort     string `mapstructure:"PORT"`
+	GRPCPort       string `mapstructure:"GRPC_PORT"`
 	UserGRPC       string `mapstructure:"USER_GRCP"`
+	BookGRPC       string `mapstructure:"BOOK_GRPC"`
+
+	BookLookupMode    string        `mapstructure:"BOOK_LOOKUP_MODE"`
+	BookLookupTimeout time.Duration `mapstructure:"BOOK_LOOKUP_TIMEOUT"`
+
+	OrphanReconcileInterval time.Duration `mapstructure:"ORPHAN_RECONCILE_INTERVAL"`
+	OrphanReconcilePageSize int           `mapstructure:"ORPHAN_RECONCILE_PAGE_SIZE"`
+	OrphanReconcileDryRun   bool          `mapstructure:"ORPHAN_RECONCILE_DRY_RUN"`
+
+	OutboxPublisher    string        `mapstructure:"OUTBOX_PUBLISHER"`
+	OutboxWebhookURL   string        `mapstructure:"OUTBOX_WEBHOOK_URL"`
+	OutboxPollInterval time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OutboxBatchSize    int           `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OutboxMaxAttempts  int           `mapstructure:"OUTBOX_MAX_ATTEMPTS"`
+
+	WebhookTimeout      time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WebhookPollInterval time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+	WebhookMaxAttempts  int           `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookBackoffBase  time.Duration `mapstructure:"WEBHOOK_BACKOFF_BASE"`
+	WebhookBackoffMax   time.Duration `mapstructure:"WEBHOOK_BACKOFF_MAX"`
+
+	StreamReplayBuffer int           `mapstructure:"STREAM_REPLAY_BUFFER"`
+	StreamHeartbeat    time.Duration `mapstructure:"STREAM_HEARTBEAT"`
+	StreamGapTimeout   time.Duration `mapstructure:"STREAM_GAP_TIMEOUT"`
+
+	CacheEnabled       bool          `mapstructure:"CACHE_ENABLED"`
+	CacheBackend       string        `mapstructure:"CACHE_BACKEND"`
+	CacheTTL           time.Duration `mapstructure:"CACHE_TTL"`
+	CacheMaxEntries    int           `mapstructure:"CACHE_MAX_ENTRIES"`
+	CacheRedisAddr     string        `mapstructure:"CACHE_REDIS_ADDR"`
+	CacheRedisPassword string        `mapstructure:"CACHE_REDIS_PASSWORD"`
+	CacheRedisDB       int           `mapstructure:"CACHE_REDIS_DB"`
+
+	AuthMode           string        `mapstructure:"AUTH_MODE"`
+	AuthRemoteFallback bool          `mapstructure:"AUTH_REMOTE_FALLBACK"`
+	AuthJWTSecret      string        `mapstructure:"AUTH_JWT_SECRET"`
+	AuthJWTIssuer      string        `mapstructure:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience    string        `mapstructure:"AUTH_JWT_AUDIENCE"`
+	AuthJWKS           string        `mapstructure:"AUTH_JWKS"`
+	AuthJWKSRefresh    time.Duration `mapstructure:"AUTH_JWKS_REFRESH"`
+
+	AuthPolicyFile   string        `mapstructure:"AUTH_POLICY_FILE"`
+	AuthPolicyReload time.Duration `mapstructure:"AUTH_POLICY_RELOAD"`
+	PublicReadRoutes bool          `mapstructure:"PUBLIC_READ_ROUTES"`
+
+	GRPCRequireAPIKey bool `mapstructure:"GRPC_REQUIRE_API_KEY"`
+
+	CORSAllowedOrigins   []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods   []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders   []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSExposedHeaders   []string      `mapstructure:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           time.Duration `mapstructure:"CORS_MAX_AGE"`
+
+	RateLimitEnabled bool   `mapstructure:"RATE_LIMIT_ENABLED"`
+	RateLimitBackend string `mapstructure:"RATE_LIMIT_BACKEND"`
+	RateLimitDefault string `mapstructure:"RATE_LIMIT_DEFAULT"`
+	RateLimitRoles   string `mapstructure:"RATE_LIMIT_ROLES"`
+	RateLimitRoutes  string `mapstructure:"RATE_LIMIT_ROUTES"`
+
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	AuthGRPCTLS        bool   `mapstructure:"AUTH_GRPC_TLS"`
+	AuthGRPCCAFile     string `mapstructure:"AUTH_GRPC_CA_FILE"`
+	AuthGRPCCertFile   string `mapstructure:"AUTH_GRPC_CERT_FILE"`
+	AuthGRPCKeyFile    string `mapstructure:"AUTH_GRPC_KEY_FILE"`
+	AuthGRPCServerName string `mapstructure:"AUTH_GRPC_SERVER_NAME"`
+
+	BookGRPCTLS        bool   `mapstructure:"BOOK_GRPC_TLS"`
+	BookGRPCCAFile     string `mapstructure:"BOOK_GRPC_CA_FILE"`
+	BookGRPCCertFile   string `mapstructure:"BOOK_GRPC_CERT_FILE"`
+	BookGRPCKeyFile    string `mapstructure:"BOOK_GRPC_KEY_FILE"`
+	BookGRPCServerName string `mapstructure:"BOOK_GRPC_SERVER_NAME"`
+
+	AuthGRPCTimeout      time.Duration `mapstructure:"AUTH_GRPC_TIMEOUT"`
+	AuthGRPCMaxAttempts  int           `mapstructure:"AUTH_GRPC_MAX_ATTEMPTS"`
+	AuthGRPCBackoff      time.Duration `mapstructure:"AUTH_GRPC_BACKOFF"`
+	AuthBreakerThreshold int           `mapstructure:"AUTH_BREAKER_THRESHOLD"`
+	AuthBreakerCooldown  time.Duration `mapstructure:"AUTH_BREAKER_COOLDOWN"`
+
+	GRPCTLSCertFile     string        `mapstructure:"GRPC_TLS_CERT_FILE"`
+	GRPCTLSKeyFile      string        `mapstructure:"GRPC_TLS_KEY_FILE"`
+	GRPCTLSClientCAFile string        `mapstructure:"GRPC_TLS_CLIENT_CA_FILE"`
+	TLSReloadInterval   time.Duration `mapstructure:"TLS_RELOAD_INTERVAL"`
+
+	AuthCacheEnabled    bool          `mapstructure:"AUTH_CACHE_ENABLED"`
+	AuthCacheTTL        time.Duration `mapstructure:"AUTH_CACHE_TTL"`
+	AuthCacheMaxEntries int           `mapstructure:"AUTH_CACHE_MAX_ENTRIES"`
+
+	HTTPCacheCategories     string `mapstructure:"HTTP_CACHE_CATEGORIES"`
+	HTTPCacheCategoryDetail string `mapstructure:"HTTP_CACHE_CATEGORY_DETAIL"`
+	HTTPCacheBookCategories string `mapstructure:"HTTP_CACHE_BOOK_CATEGORIES"`
 }
 
 var ENV *Config
@@ -23,6 +118,58 @@ func LoadConfig() {
 	fang.SetConfigName(".env")
 	fang.SetConfigType("env")
 
+	fang.SetDefault("GRPC_PORT", "50053")
+	fang.SetDefault("BOOK_LOOKUP_MODE", "lenient")
+	fang.SetDefault("BOOK_LOOKUP_TIMEOUT", "2s")
+	fang.SetDefault("ORPHAN_RECONCILE_INTERVAL", "24h")
+	fang.SetDefault("ORPHAN_RECONCILE_PAGE_SIZE", 100)
+	fang.SetDefault("ORPHAN_RECONCILE_DRY_RUN", true)
+	fang.SetDefault("OUTBOX_PUBLISHER", "log")
+	fang.SetDefault("OUTBOX_POLL_INTERVAL", "2s")
+	fang.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	fang.SetDefault("OUTBOX_MAX_ATTEMPTS", 10)
+	fang.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	fang.SetDefault("WEBHOOK_POLL_INTERVAL", "5s")
+	fang.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	fang.SetDefault("WEBHOOK_BACKOFF_BASE", "30s")
+	fang.SetDefault("WEBHOOK_BACKOFF_MAX", "1h")
+	fang.SetDefault("STREAM_REPLAY_BUFFER", 1000)
+	fang.SetDefault("STREAM_HEARTBEAT", "15s")
+	fang.SetDefault("STREAM_GAP_TIMEOUT", "5s")
+	fang.SetDefault("CACHE_ENABLED", true)
+	fang.SetDefault("CACHE_BACKEND", "memory")
+	fang.SetDefault("CACHE_TTL", "5m")
+	fang.SetDefault("CACHE_MAX_ENTRIES", 10000)
+	fang.SetDefault("AUTH_MODE", "remote")
+	fang.SetDefault("AUTH_JWKS_REFRESH", "1h")
+	fang.SetDefault("AUTH_POLICY_FILE", "policy.json")
+	fang.SetDefault("AUTH_POLICY_RELOAD", "30s")
+	fang.SetDefault("PUBLIC_READ_ROUTES", false)
+	fang.SetDefault("GRPC_REQUIRE_API_KEY", true)
+	fang.SetDefault("CORS_ALLOWED_ORIGINS", "*")
+	fang.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS")
+	fang.SetDefault("CORS_ALLOWED_HEADERS", "Authorization,Content-Type,Accept,X-API-Key,X-Request-ID,If-None-Match,If-Modified-Since,Last-Event-ID")
+	fang.SetDefault("CORS_EXPOSED_HEADERS", "ETag,Last-Modified,X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After")
+	fang.SetDefault("CORS_ALLOW_CREDENTIALS", false)
+	fang.SetDefault("CORS_MAX_AGE", "10m")
+	fang.SetDefault("RATE_LIMIT_ENABLED", true)
+	fang.SetDefault("RATE_LIMIT_BACKEND", "memory")
+	fang.SetDefault("RATE_LIMIT_DEFAULT", "10:20")
+	fang.SetDefault("AUTH_GRPC_TLS", false)
+	fang.SetDefault("BOOK_GRPC_TLS", false)
+	fang.SetDefault("AUTH_GRPC_TIMEOUT", "1s")
+	fang.SetDefault("AUTH_GRPC_MAX_ATTEMPTS", 3)
+	fang.SetDefault("AUTH_GRPC_BACKOFF", "100ms")
+	fang.SetDefault("AUTH_BREAKER_THRESHOLD", 5)
+	fang.SetDefault("AUTH_BREAKER_COOLDOWN", "30s")
+	fang.SetDefault("TLS_RELOAD_INTERVAL", "1m")
+	fang.SetDefault("AUTH_CACHE_ENABLED", true)
+	fang.SetDefault("AUTH_CACHE_TTL", "1m")
+	fang.SetDefault("AUTH_CACHE_MAX_ENTRIES", 10000)
+	fang.SetDefault("HTTP_CACHE_CATEGORIES", "max-age=30")
+	fang.SetDefault("HTTP_CACHE_CATEGORY_DETAIL", "max-age=60")
+	fang.SetDefault("HTTP_CACHE_BOOK_CATEGORIES", "max-age=60")
+
 	err := fang.ReadInConfig()
 	if err != nil {
 		panic(err)
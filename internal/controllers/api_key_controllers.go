@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/params"
+	"library-api-category/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController interface {
+	CreateAPIKey(ctx *gin.Context)
+	GetAllAPIKeys(ctx *gin.Context)
+	RevokeAPIKey(ctx *gin.Context)
+}
+
+type APIKeyControllerImpl struct {
+	APIKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(APIKeyService services.APIKeyService) APIKeyController {
+	return &APIKeyControllerImpl{
+		APIKeyService: APIKeyService,
+	}
+}
+
+func (controller *APIKeyControllerImpl) CreateAPIKey(ctx *gin.Context) {
+	var req = new(params.APIKeyRequest)
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	result, custErr := controller.APIKeyService.CreateAPIKey(ctx, req)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.CreatedSuccessWithPayload(result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *APIKeyControllerImpl) GetAllAPIKeys(ctx *gin.Context) {
+	result, custErr := controller.APIKeyService.GetAllAPIKeys(ctx)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data api keys", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *APIKeyControllerImpl) RevokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	custErr := controller.APIKeyService.RevokeAPIKey(ctx, uint64(id))
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success revoke api key", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/models"
+	"library-api-category/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController interface {
+	GetAllAuditLogs(ctx *gin.Context)
+}
+
+type AuditControllerImpl struct {
+	AuditService services.AuditService
+}
+
+func NewAuditController(AuditService services.AuditService) AuditController {
+	return &AuditControllerImpl{
+		AuditService: AuditService,
+	}
+}
+
+func (controller *AuditControllerImpl) GetAllAuditLogs(ctx *gin.Context) {
+	page := ctx.Query("page")
+	limit := ctx.Query("limit")
+
+	pageNum := 1
+	limitSize := 20
+
+	if page != "" {
+		parsedPage, err := strconv.Atoi(page)
+		if err == nil && parsedPage > 0 {
+			pageNum = parsedPage
+		}
+	}
+
+	if limit != "" {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err == nil && parsedLimit > 0 {
+			limitSize = parsedLimit
+		}
+	}
+
+	filter := models.AuditFilter{
+		Action:     ctx.Query("action"),
+		EntityType: ctx.Query("entity_type"),
+	}
+
+	var err error
+	if actorID := ctx.Query("actor_id"); actorID != "" {
+		filter.ActorID, err = strconv.ParseUint(actorID, 10, 64)
+		if err != nil {
+			resp := response.BadRequestError("actor_id must be a positive number")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+	}
+	if entityID := ctx.Query("entity_id"); entityID != "" {
+		filter.EntityID, err = strconv.ParseUint(entityID, 10, 64)
+		if err != nil {
+			resp := response.BadRequestError("entity_id must be a positive number")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+	}
+	if from := ctx.Query("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			resp := response.BadRequestError("from must be an RFC3339 timestamp")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+	}
+	if to := ctx.Query("to"); to != "" {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			resp := response.BadRequestError("to must be an RFC3339 timestamp")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+	}
+
+	pagination := models.Pagination{
+		Page:     pageNum,
+		Offset:   (pageNum - 1) * limitSize,
+		PageSize: limitSize,
+	}
+
+	result, custErr := controller.AuditService.GetAllAuditLogs(ctx, &filter, &pagination)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	type Response struct {
+		AuditLogs  interface{} `json:"audit_logs"`
+		Pagination interface{} `json:"pagination"`
+	}
+
+	var responses Response
+	responses.AuditLogs = result
+	responses.Pagination = pagination
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data audit logs", responses)
+	ctx.JSON(resp.StatusCode, resp)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"library-api-category/internal/cache"
+	"library-api-category/internal/commons/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheController interface {
+	GetCacheStats(ctx *gin.Context)
+}
+
+type CacheControllerImpl struct {
+	StatsReporters []cache.StatsReporter
+}
+
+func NewCacheController(StatsReporters ...cache.StatsReporter) CacheController {
+	return &CacheControllerImpl{
+		StatsReporters: StatsReporters,
+	}
+}
+
+func (controller *CacheControllerImpl) GetCacheStats(ctx *gin.Context) {
+	stats := map[string]cache.Stats{}
+	for _, reporter := range controller.StatsReporters {
+		for name, stat := range reporter.CacheStats() {
+			stats[name] = stat
+		}
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get cache statistics", stats)
+	ctx.JSON(resp.StatusCode, resp)
+}
@@ -7,6 +7,8 @@ import (
 	"library-api-category/internal/services"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,7 +20,12 @@ type CategoryController interface {
 	DeleteCategory(ctx *gin.Context)
 	GetAllCategories(ctx *gin.Context)
 	AddBookCategory(ctx *gin.Context)
+	RemoveBookCategory(ctx *gin.Context)
 	ListCategoryOfBook(ctx *gin.Context)
+	ListCategoriesOfBooks(ctx *gin.Context)
+	ListCategoryRevisions(ctx *gin.Context)
+	DiffCategoryRevisions(ctx *gin.Context)
+	RevertCategory(ctx *gin.Context)
 }
 
 type CategoryControllerImpl struct {
@@ -63,13 +70,17 @@ func (controller *CategoryControllerImpl) GetDetailCategory(ctx *gin.Context) {
 		return
 	}
 
-	result, custErr := controller.CategoryService.GetDetailCategory(ctx, uint64(id))
+	result, custErr := controller.CategoryService.GetDetailCategory(ctx, uint64(id), includesCounts(ctx))
 
 	if custErr != nil {
 		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
 		return
 	}
 
+	if !includesCounts(ctx) {
+		setLastModified(ctx, result)
+	}
+
 	resp := response.GeneralSuccessCustomMessageAndPayload("Success get detail category", result)
 	ctx.JSON(resp.StatusCode, resp)
 }
@@ -119,6 +130,11 @@ func (controller *CategoryControllerImpl) DeleteCategory(ctx *gin.Context) {
 }
 
 func (controller *CategoryControllerImpl) GetAllCategories(ctx *gin.Context) {
+	if ids, ok := ctx.GetQuery("ids"); ok {
+		controller.batchGetCategories(ctx, ids)
+		return
+	}
+
 	page := ctx.Query("page")
 	limit := ctx.Query("limit")
 
@@ -145,7 +161,7 @@ func (controller *CategoryControllerImpl) GetAllCategories(ctx *gin.Context) {
 		PageSize: limitSize,
 	}
 
-	result, custErr := controller.CategoryService.GetAllCategories(ctx, &pagination)
+	result, custErr := controller.CategoryService.GetAllCategories(ctx, &pagination, includesCounts(ctx))
 
 	if custErr != nil {
 		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
@@ -157,6 +173,10 @@ func (controller *CategoryControllerImpl) GetAllCategories(ctx *gin.Context) {
 		Pagination interface{} `json:"pagination"`
 	}
 
+	if !includesCounts(ctx) {
+		setLastModified(ctx, result...)
+	}
+
 	var responses Response
 	responses.Categories = result
 	responses.Pagination = pagination
@@ -165,6 +185,25 @@ func (controller *CategoryControllerImpl) GetAllCategories(ctx *gin.Context) {
 	ctx.JSON(resp.StatusCode, resp)
 }
 
+func (controller *CategoryControllerImpl) batchGetCategories(ctx *gin.Context, rawIDs string) {
+	ids, err := parseIDList(rawIDs)
+	if err != nil {
+		resp := response.BadRequestError("ids must be a comma separated list of category ids")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	result, custErr := controller.CategoryService.BatchGetCategories(ctx, ids)
+
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data categories", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
 func (controller *CategoryControllerImpl) AddBookCategory(ctx *gin.Context) {
 	var req = new(params.BookCategoryRequest)
 
@@ -187,6 +226,40 @@ func (controller *CategoryControllerImpl) AddBookCategory(ctx *gin.Context) {
 	ctx.JSON(resp.StatusCode, resp)
 }
 
+func (controller *CategoryControllerImpl) RemoveBookCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	bookID, err := strconv.Atoi(ctx.Param("book_id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	req := params.BookCategoryRequest{
+		BookID:     uint64(bookID),
+		CategoryID: uint64(id),
+	}
+
+	custErr := controller.CategoryService.RemoveBookCategory(ctx, &req)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success remove book from category", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
 func (controller *CategoryControllerImpl) ListCategoryOfBook(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
@@ -203,6 +276,154 @@ func (controller *CategoryControllerImpl) ListCategoryOfBook(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
 		return
 	}
+
 	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data list book of categories", result)
 	ctx.JSON(resp.StatusCode, resp)
 }
+
+func (controller *CategoryControllerImpl) ListCategoriesOfBooks(ctx *gin.Context) {
+	bookIDs, err := parseIDList(ctx.Query("book_ids"))
+	if err != nil {
+		resp := response.BadRequestError("book_ids must be a comma separated list of book ids")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	result, custErr := controller.CategoryService.ListCategoriesOfBooks(ctx, bookIDs)
+
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data list categories of books", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *CategoryControllerImpl) ListCategoryRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	result, custErr := controller.CategoryService.ListCategoryRevisions(ctx, uint64(id))
+
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data category revisions", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *CategoryControllerImpl) DiffCategoryRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	from, err := strconv.ParseUint(ctx.Query("from"), 10, 64)
+	if err != nil {
+		resp := response.BadRequestError("from must be a revision number")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	to, err := strconv.ParseUint(ctx.Query("to"), 10, 64)
+	if err != nil {
+		resp := response.BadRequestError("to must be a revision number")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	result, custErr := controller.CategoryService.DiffCategoryRevisions(ctx, uint64(id), from, to)
+
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get diff of category revisions", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *CategoryControllerImpl) RevertCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	revision, err := strconv.ParseUint(ctx.Param("revision"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	custErr := controller.CategoryService.RevertCategory(ctx, uint64(id), revision)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success revert data category", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+// includesCounts reports whether the request asked for book counts with
+// ?include=counts. Counts change without touching updated_at, so such
+// responses get no Last-Modified and are revalidated by ETag only.
+func includesCounts(ctx *gin.Context) bool {
+	for _, include := range strings.Split(ctx.Query("include"), ",") {
+		if strings.TrimSpace(include) == "counts" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIDList parses a comma separated list of IDs such as "1,2,3".
+func parseIDList(raw string) ([]uint64, error) {
+	var ids []uint64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// setLastModified sets Last-Modified to the newest updated_at of categories so
+// HTTP caches can revalidate with If-Modified-Since.
+func setLastModified(ctx *gin.Context, categories ...*params.CategoryResponse) {
+	var lastModified time.Time
+	for _, cate := range categories {
+		if cate.UpdatedAt.After(lastModified) {
+			lastModified = cate.UpdatedAt
+		}
+	}
+
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryStatsController interface {
+	GetCategoryStats(ctx *gin.Context)
+}
+
+type CategoryStatsControllerImpl struct {
+	CategoryStatsService services.CategoryStatsService
+}
+
+func NewCategoryStatsController(CategoryStatsService services.CategoryStatsService) CategoryStatsController {
+	return &CategoryStatsControllerImpl{
+		CategoryStatsService: CategoryStatsService,
+	}
+}
+
+func (controller *CategoryStatsControllerImpl) GetCategoryStats(ctx *gin.Context) {
+	top := 10
+	days := 30
+
+	if raw := ctx.Query("top"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 100 {
+			resp := response.BadRequestError("top must be a number between 1 and 100")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		top = parsed
+	}
+
+	if raw := ctx.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 365 {
+			resp := response.BadRequestError("days must be a number between 1 and 365")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		days = parsed
+	}
+
+	result, custErr := controller.CategoryStatsService.GetCategoryStats(ctx, top, days)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get category statistics", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyController interface {
+	GetPolicy(ctx *gin.Context)
+	ReloadPolicy(ctx *gin.Context)
+}
+
+type PolicyControllerImpl struct {
+	Engine *policy.Engine
+}
+
+func NewPolicyController(Engine *policy.Engine) PolicyController {
+	return &PolicyControllerImpl{
+		Engine: Engine,
+	}
+}
+
+func (controller *PolicyControllerImpl) GetPolicy(ctx *gin.Context) {
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get authorization policy", controller.Engine.Policy())
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *PolicyControllerImpl) ReloadPolicy(ctx *gin.Context) {
+	err := controller.Engine.Reload()
+	if err != nil {
+		resp := response.GeneralError("Failed to reload the policy, the previous one is kept: " + err.Error())
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success reload authorization policy", controller.Engine.Policy())
+	ctx.JSON(resp.StatusCode, resp)
+}
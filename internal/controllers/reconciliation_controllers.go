@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/jobs"
+	"library-api-category/internal/params"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationController interface {
+	TriggerOrphanReconciliation(ctx *gin.Context)
+	GetOrphanReconciliation(ctx *gin.Context)
+}
+
+type ReconciliationControllerImpl struct {
+	OrphanReconciler *jobs.OrphanReconciler
+}
+
+func NewReconciliationController(OrphanReconciler *jobs.OrphanReconciler) ReconciliationController {
+	return &ReconciliationControllerImpl{
+		OrphanReconciler: OrphanReconciler,
+	}
+}
+
+// TriggerOrphanReconciliation starts a run and answers 202 with its progress.
+// Runs are dry unless ?dry_run=false is given.
+func (controller *ReconciliationControllerImpl) TriggerOrphanReconciliation(ctx *gin.Context) {
+	if controller.OrphanReconciler == nil {
+		resp := response.ServiceUnavailableError("orphan reconciliation needs BOOK_GRPC to be configured")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	dryRun := true
+	if raw := ctx.Query("dry_run"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			resp := response.BadRequestError("dry_run must be true or false")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		dryRun = parsed
+	}
+
+	err := controller.OrphanReconciler.Trigger(ctx, dryRun)
+	if errors.Is(err, jobs.ErrReconcileRunning) {
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Orphan reconciliation started", toOrphanReconciliationResponse(controller.OrphanReconciler.Progress()))
+	resp.StatusCode = http.StatusAccepted
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *ReconciliationControllerImpl) GetOrphanReconciliation(ctx *gin.Context) {
+	if controller.OrphanReconciler == nil {
+		resp := response.ServiceUnavailableError("orphan reconciliation needs BOOK_GRPC to be configured")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get orphan reconciliation progress", toOrphanReconciliationResponse(controller.OrphanReconciler.Progress()))
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func toOrphanReconciliationResponse(progress jobs.OrphanProgress) *params.OrphanReconciliationResponse {
+	result := &params.OrphanReconciliationResponse{
+		Running:       progress.Running,
+		DryRun:        progress.DryRun,
+		LastBookID:    progress.LastBookID,
+		CheckedBooks:  progress.CheckedBooks,
+		OrphanBooks:   progress.OrphanBooks,
+		OrphanBookIDs: progress.OrphanBookIDs,
+		Removed:       progress.Removed,
+		Error:         progress.Error,
+	}
+	if result.OrphanBookIDs == nil {
+		result.OrphanBookIDs = []uint64{}
+	}
+	if !progress.StartedAt.IsZero() {
+		result.StartedAt = &progress.StartedAt
+	}
+	if !progress.FinishedAt.IsZero() {
+		result.FinishedAt = &progress.FinishedAt
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"library-api-category/internal/events"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StreamController interface {
+	StreamCategoryEvents(ctx *gin.Context)
+}
+
+type StreamControllerImpl struct {
+	Broker    *events.Broker
+	Heartbeat time.Duration
+}
+
+func NewStreamController(broker *events.Broker, heartbeat time.Duration) StreamController {
+	return &StreamControllerImpl{
+		Broker:    broker,
+		Heartbeat: heartbeat,
+	}
+}
+
+// StreamCategoryEvents pushes category and assignment changes as server-sent
+// events. Clients resume with the Last-Event-ID header (or last_event_id query
+// for EventSource polyfills); a "reset" event means the gap could not be
+// replayed and the client should reload its data.
+func (controller *StreamControllerImpl) StreamCategoryEvents(ctx *gin.Context) {
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+
+	var lastID uint64
+	if lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  false,
+				"message": "Last-Event-ID must be a number",
+			})
+			return
+		}
+	}
+
+	sub, replay, complete := controller.Broker.Subscribe(ctx.Request.Context(), lastID)
+	defer sub.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if !complete {
+		fmt.Fprintf(ctx.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, msg := range replay {
+		writeEvent(ctx, msg)
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(controller.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				// dropped for falling behind, the client reconnects with Last-Event-ID
+				return
+			}
+			writeEvent(ctx, msg)
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprintf(ctx.Writer, ": ping\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(ctx *gin.Context, msg *events.Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+}
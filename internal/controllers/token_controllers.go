@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/grpc/client"
+	"library-api-category/internal/params"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenController interface {
+	RevokeTokens(ctx *gin.Context)
+}
+
+type TokenControllerImpl struct {
+	Revocations *client.Revocations
+}
+
+func NewTokenController(Revocations *client.Revocations) TokenController {
+	return &TokenControllerImpl{
+		Revocations: Revocations,
+	}
+}
+
+// RevokeTokens drops a token, or every token of a user, from the validation
+// cache of every replica so the next request is checked by the user service.
+func (controller *TokenControllerImpl) RevokeTokens(ctx *gin.Context) {
+	var req = new(params.TokenRevocationRequest)
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	if req.Token == "" && req.AuthID == 0 {
+		resp := response.BadRequestError("token or auth_id is required")
+		ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	if controller.Revocations != nil {
+		err = controller.Revocations.Publish(ctx, client.NewRevocation(req.Token, req.AuthID))
+		if err != nil {
+			resp := response.GeneralError("Failed to revoke tokens on other replicas: " + err.Error())
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success revoke cached tokens", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/models"
+	"library-api-category/internal/params"
+	"library-api-category/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController interface {
+	CreateSubscription(ctx *gin.Context)
+	GetAllSubscriptions(ctx *gin.Context)
+	GetDetailSubscription(ctx *gin.Context)
+	UpdateSubscription(ctx *gin.Context)
+	DeleteSubscription(ctx *gin.Context)
+	GetSubscriptionDeliveries(ctx *gin.Context)
+	GetDeadLetters(ctx *gin.Context)
+	RedeliverDelivery(ctx *gin.Context)
+}
+
+type WebhookControllerImpl struct {
+	WebhookService services.WebhookService
+}
+
+func NewWebhookController(WebhookService services.WebhookService) WebhookController {
+	return &WebhookControllerImpl{
+		WebhookService: WebhookService,
+	}
+}
+
+func (controller *WebhookControllerImpl) CreateSubscription(ctx *gin.Context) {
+	var req = new(params.WebhookSubscriptionRequest)
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	result, custErr := controller.WebhookService.CreateSubscription(ctx, req)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.CreatedSuccessWithPayload(result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *WebhookControllerImpl) GetAllSubscriptions(ctx *gin.Context) {
+	result, custErr := controller.WebhookService.GetAllSubscriptions(ctx)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data webhook subscriptions", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *WebhookControllerImpl) GetDetailSubscription(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	result, custErr := controller.WebhookService.GetDetailSubscription(ctx, uint64(id))
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get detail webhook subscription", result)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *WebhookControllerImpl) UpdateSubscription(ctx *gin.Context) {
+	var req = new(params.WebhookSubscriptionRequest)
+
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	custErr := controller.WebhookService.UpdateSubscription(ctx, uint64(id), req)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success update data webhook subscription", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *WebhookControllerImpl) DeleteSubscription(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	custErr := controller.WebhookService.DeleteSubscription(ctx, uint64(id))
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success delete data webhook subscription", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *WebhookControllerImpl) GetSubscriptionDeliveries(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	filter := models.WebhookDeliveryFilter{
+		SubscriptionID: uint64(id),
+		Status:         ctx.Query("status"),
+	}
+
+	controller.getDeliveries(ctx, &filter)
+}
+
+func (controller *WebhookControllerImpl) GetDeadLetters(ctx *gin.Context) {
+	filter := models.WebhookDeliveryFilter{
+		Status: models.WebhookDeliveryDead,
+	}
+
+	controller.getDeliveries(ctx, &filter)
+}
+
+func (controller *WebhookControllerImpl) RedeliverDelivery(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err,
+		})
+		return
+	}
+
+	custErr := controller.WebhookService.RedeliverDelivery(ctx, uint64(id))
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success schedule webhook redelivery", nil)
+	ctx.JSON(resp.StatusCode, resp)
+}
+
+func (controller *WebhookControllerImpl) getDeliveries(ctx *gin.Context, filter *models.WebhookDeliveryFilter) {
+	page := ctx.Query("page")
+	limit := ctx.Query("limit")
+
+	pageNum := 1
+	limitSize := 20
+
+	if page != "" {
+		parsedPage, err := strconv.Atoi(page)
+		if err == nil && parsedPage > 0 {
+			pageNum = parsedPage
+		}
+	}
+
+	if limit != "" {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err == nil && parsedLimit > 0 {
+			limitSize = parsedLimit
+		}
+	}
+
+	pagination := models.Pagination{
+		Page:     pageNum,
+		Offset:   (pageNum - 1) * limitSize,
+		PageSize: limitSize,
+	}
+
+	result, custErr := controller.WebhookService.GetAllDeliveries(ctx, filter, &pagination)
+	if custErr != nil {
+		ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+		return
+	}
+
+	type Response struct {
+		Deliveries interface{} `json:"deliveries"`
+		Pagination interface{} `json:"pagination"`
+	}
+
+	var responses Response
+	responses.Deliveries = result
+	responses.Pagination = pagination
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data webhook deliveries", responses)
+	ctx.JSON(resp.StatusCode, resp)
+}
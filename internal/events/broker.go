@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Backlog reads published messages in ID order, used to resume subscribers
+// from before the oldest buffered message.
+type Backlog interface {
+	MessagesAfter(ctx context.Context, afterID uint64, limit int) ([]*Message, error)
+}
+
+// Broker fans messages out to in-process subscribers such as SSE and gRPC
+// streams, keeping the most recent messages so reconnecting clients can resume.
+// Clients that are further behind are resumed from the backlog.
+type Broker struct {
+	mu       sync.Mutex
+	buffer   []*Message
+	capacity int
+	backlog  Backlog
+	lastID   uint64
+	// since is the ID after which every published message is still buffered,
+	// it is only known once started is set.
+	since       uint64
+	started     bool
+	subscribers map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C      chan *Message
+	broker *Broker
+}
+
+func NewBroker(capacity int, backlog Backlog) *Broker {
+	return &Broker{
+		capacity:    capacity,
+		backlog:     backlog,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Seek positions a broker that has not published anything yet after afterID.
+// Messages up to afterID are then only available from the backlog.
+func (broker *Broker) Seek(afterID uint64) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.started {
+		return
+	}
+	broker.lastID = afterID
+	broker.since = afterID
+	broker.started = true
+}
+
+// Publish buffers msg and hands it to every subscriber. Messages must arrive in
+// increasing ID order, anything at or below the last published ID is ignored.
+// A subscriber that cannot keep up is dropped by closing its channel.
+func (broker *Broker) Publish(msg *Message) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if msg.ID <= broker.lastID {
+		return
+	}
+	if !broker.started {
+		broker.since = msg.ID - 1
+		broker.started = true
+	}
+	broker.lastID = msg.ID
+
+	broker.buffer = append(broker.buffer, msg)
+	if len(broker.buffer) > broker.capacity {
+		broker.since = broker.buffer[0].ID
+		broker.buffer[0] = nil
+		broker.buffer = broker.buffer[1:]
+	}
+
+	for sub := range broker.subscribers {
+		select {
+		case sub.C <- msg:
+		default:
+			delete(broker.subscribers, sub)
+			close(sub.C)
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the messages after lastID,
+// from the buffer and, for older ones, from the backlog. complete is false
+// when they cannot all be returned, because more than a buffer's worth was
+// missed or the backlog failed, in which case the caller should tell its
+// client to reload from scratch.
+func (broker *Broker) Subscribe(ctx context.Context, lastID uint64) (sub *Subscription, replay []*Message, complete bool) {
+	broker.mu.Lock()
+	sub = &Subscription{
+		C:      make(chan *Message, 64),
+		broker: broker,
+	}
+	broker.subscribers[sub] = struct{}{}
+
+	if lastID == 0 {
+		broker.mu.Unlock()
+		return sub, nil, true
+	}
+
+	for _, msg := range broker.buffer {
+		if msg.ID > lastID {
+			replay = append(replay, msg)
+		}
+	}
+	since, started := broker.since, broker.started
+	broker.mu.Unlock()
+
+	if started && lastID >= since {
+		return sub, replay, true
+	}
+	if !started || broker.backlog == nil {
+		return sub, replay, false
+	}
+
+	// read outside the lock, anything published meanwhile reaches sub.C
+	missed, err := broker.backlog.MessagesAfter(ctx, lastID, broker.capacity+1)
+	if err != nil {
+		log.Printf("broker backlog: %v", err)
+		return sub, replay, false
+	}
+
+	var older []*Message
+	for _, msg := range missed {
+		if msg.ID <= since {
+			older = append(older, msg)
+		}
+	}
+	if len(older) > broker.capacity {
+		return sub, replay, false
+	}
+	return sub, append(older, replay...), true
+}
+
+// LastID returns the ID of the newest published message, or 0 if none.
+func (broker *Broker) LastID() uint64 {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	return broker.lastID
+}
+
+func (sub *Subscription) Close() {
+	sub.broker.mu.Lock()
+	defer sub.broker.mu.Unlock()
+
+	if _, ok := sub.broker.subscribers[sub]; ok {
+		delete(sub.broker.subscribers, sub)
+		close(sub.C)
+	}
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func publish(broker *Broker, ids ...uint64) {
+	for _, id := range ids {
+		broker.Publish(&Message{ID: id})
+	}
+}
+
+func TestBrokerSubscribe(t *testing.T) {
+	tests := []struct {
+		name         string
+		seek         uint64
+		published    []uint64
+		outbox       []uint64
+		outboxErr    error
+		lastID       uint64
+		wantReplay   []uint64
+		wantComplete bool
+	}{
+		{
+			name:         "new subscriber",
+			published:    []uint64{1, 2},
+			wantComplete: true,
+		},
+		{
+			name:         "resume from the buffer",
+			published:    []uint64{1, 2, 3},
+			lastID:       1,
+			wantReplay:   []uint64{2, 3},
+			wantComplete: true,
+		},
+		{
+			name:         "resume from the outbox",
+			seek:         5,
+			published:    []uint64{6, 7},
+			outbox:       []uint64{1, 2, 3, 4, 5, 6, 7},
+			lastID:       3,
+			wantReplay:   []uint64{4, 5, 6, 7},
+			wantComplete: true,
+		},
+		{
+			name:         "resume from the outbox past evicted messages",
+			published:    []uint64{1, 2, 3, 4, 5},
+			outbox:       []uint64{1, 2, 3, 4, 5},
+			lastID:       1,
+			wantReplay:   []uint64{2, 3, 4, 5},
+			wantComplete: true,
+		},
+		{
+			name:       "too far behind",
+			seek:       10,
+			published:  []uint64{11},
+			outbox:     []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+			lastID:     1,
+			wantReplay: []uint64{11},
+		},
+		{
+			name:       "outbox unavailable",
+			seek:       5,
+			published:  []uint64{6},
+			outbox:     []uint64{1, 2, 3, 4, 5, 6},
+			outboxErr:  errors.New("connection refused"),
+			lastID:     3,
+			wantReplay: []uint64{6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newTestDB(t)
+			outbox := newMemoryOutbox(tt.outbox...)
+			outbox.err = tt.outboxErr
+			if tt.lastID != 0 {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			}
+
+			broker := NewBroker(3, NewOutboxBacklog(db, outbox))
+			if tt.seek != 0 {
+				broker.Seek(tt.seek)
+			}
+			publish(broker, tt.published...)
+
+			sub, replay, complete := broker.Subscribe(context.Background(), tt.lastID)
+			defer sub.Close()
+
+			if got := messageIDs(replay); !equalIDs(got, tt.wantReplay...) {
+				t.Errorf("replay = %v, want %v", got, tt.wantReplay)
+			}
+			if complete != tt.wantComplete {
+				t.Errorf("complete = %v, want %v", complete, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	broker := NewBroker(10, nil)
+	sub, _, _ := broker.Subscribe(context.Background(), 0)
+	defer sub.Close()
+
+	publish(broker, 1, 2, 2, 1, 3)
+
+	var got []uint64
+	for len(got) < 3 {
+		got = append(got, (<-sub.C).ID)
+	}
+	if !equalIDs(got, 1, 2, 3) {
+		t.Errorf("received %v, want [1 2 3]", got)
+	}
+	if broker.LastID() != 3 {
+		t.Errorf("LastID = %d, want 3", broker.LastID())
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	broker := NewBroker(10, nil)
+	sub, _, _ := broker.Subscribe(context.Background(), 0)
+
+	for id := uint64(1); id <= uint64(cap(sub.C))+1; id++ {
+		broker.Publish(&Message{ID: id})
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	if received != cap(sub.C) {
+		t.Errorf("received %d before being dropped, want %d", received, cap(sub.C))
+	}
+	sub.Close()
+}
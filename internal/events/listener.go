@@ -0,0 +1,185 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"library-api-category/internal/repositories"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Listener feeds the Broker from Postgres NOTIFY so every replica sees the
+// changes committed by the others. Notifications only carry the event id, the
+// events themselves are read from the outbox in id order.
+//
+// Ids are taken when an event is inserted but become visible when its
+// transaction commits, so a missing id may still show up. Events after a gap
+// are held back until it fills or has been open for GapTimeout, after which the
+// missing ids are taken as rolled back.
+type Listener struct {
+	DSN              string
+	DB               *sql.DB
+	OutboxRepository repositories.OutboxRepository
+	Broker           *Broker
+	GapTimeout       time.Duration
+
+	// gapAfter is the last published id when gapSince was recorded.
+	gapAfter uint64
+	gapSince time.Time
+}
+
+func NewListener(dsn string, db *sql.DB, OutboxRepository repositories.OutboxRepository, broker *Broker, gapTimeout time.Duration) *Listener {
+	return &Listener{
+		DSN:              dsn,
+		DB:               db,
+		OutboxRepository: OutboxRepository,
+		Broker:           broker,
+		GapTimeout:       gapTimeout,
+	}
+}
+
+// OutboxBacklog serves the Broker's backlog from the outbox.
+type OutboxBacklog struct {
+	DB               *sql.DB
+	OutboxRepository repositories.OutboxRepository
+}
+
+func NewOutboxBacklog(db *sql.DB, OutboxRepository repositories.OutboxRepository) *OutboxBacklog {
+	return &OutboxBacklog{
+		DB:               db,
+		OutboxRepository: OutboxRepository,
+	}
+}
+
+func (backlog *OutboxBacklog) MessagesAfter(ctx context.Context, afterID uint64, limit int) ([]*Message, error) {
+	tx, err := backlog.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	events, err := backlog.OutboxRepository.ListEventsAfter(ctx, tx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, len(events))
+	for i, event := range events {
+		messages[i] = NewMessage(event)
+	}
+	return messages, nil
+}
+
+func (listener *Listener) Run(ctx context.Context) {
+	for {
+		err := listener.seek(ctx)
+		if err == nil {
+			break
+		}
+		log.Printf("outbox listener: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	pqListener := pq.NewListener(listener.DSN, time.Second, 30*time.Second, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("outbox listener: %v", err)
+		}
+	})
+	defer pqListener.Close()
+
+	err := pqListener.Listen(repositories.OutboxChannel)
+	if err != nil {
+		log.Printf("outbox listener: %v", err)
+		return
+	}
+
+	// pick up what committed between seeking and listening, then after every
+	// notification; a nil notification means the connection was re-established
+	// and notifications may have been lost, catching up covers both cases
+	recheck := time.After(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pqListener.Notify:
+		case <-recheck:
+		case <-time.After(90 * time.Second):
+			go pqListener.Ping()
+			continue
+		}
+
+		recheck = nil
+		waiting, err := listener.catchUp(ctx, listener.Broker.LastID())
+		if err != nil {
+			log.Printf("outbox listener: %v", err)
+		}
+		if waiting {
+			recheck = time.After(listener.GapTimeout)
+		}
+	}
+}
+
+// seek starts the broker at the newest event, so clients resuming from an
+// earlier one are served from the outbox instead of told to reload.
+func (listener *Listener) seek(ctx context.Context) error {
+	tx, err := listener.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	latestID, err := listener.OutboxRepository.LatestEventID(ctx, tx)
+	if err != nil {
+		return err
+	}
+	listener.Broker.Seek(latestID)
+	return nil
+}
+
+// catchUp publishes the events after afterID in id order. waiting is true when
+// it stopped at a gap that has not timed out yet.
+func (listener *Listener) catchUp(ctx context.Context, afterID uint64) (waiting bool, err error) {
+	const batchSize = 100
+
+	for {
+		tx, err := listener.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return false, err
+		}
+
+		events, err := listener.OutboxRepository.ListEventsAfter(ctx, tx, afterID, batchSize)
+		tx.Rollback()
+		if err != nil {
+			return false, err
+		}
+
+		for _, event := range events {
+			if event.ID != afterID+1 && !listener.gapExpired(afterID) {
+				return true, nil
+			}
+			listener.Broker.Publish(NewMessage(event))
+			afterID = event.ID
+		}
+
+		if len(events) < batchSize {
+			return false, nil
+		}
+	}
+}
+
+// gapExpired reports whether the ids right after afterID have been missing for
+// GapTimeout.
+func (listener *Listener) gapExpired(afterID uint64) bool {
+	if listener.gapSince.IsZero() || listener.gapAfter != afterID {
+		listener.gapAfter = afterID
+		listener.gapSince = time.Now()
+	}
+	return time.Since(listener.gapSince) >= listener.GapTimeout
+}
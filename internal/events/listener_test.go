@@ -0,0 +1,115 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestListener(t *testing.T, gapTimeout time.Duration, ids ...uint64) (*Listener, *memoryOutbox, *Subscription) {
+	t.Helper()
+
+	db, mock := newTestDB(t)
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 10; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+
+	outbox := newMemoryOutbox(ids...)
+	broker := NewBroker(10, nil)
+	sub, _, _ := broker.Subscribe(context.Background(), 0)
+	t.Cleanup(sub.Close)
+	return NewListener("", db, outbox, broker, gapTimeout), outbox, sub
+}
+
+func received(sub *Subscription) []uint64 {
+	var ids []uint64
+	for {
+		select {
+		case msg := <-sub.C:
+			ids = append(ids, msg.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestListenerHoldsBackUncommittedIDs(t *testing.T) {
+	listener, outbox, sub := newTestListener(t, time.Hour, 1, 2, 3, 4)
+	outbox.uncommitted[3] = true
+
+	waiting, err := listener.catchUp(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waiting {
+		t.Errorf("not waiting on the gap at 3")
+	}
+	if got := received(sub); !equalIDs(got, 1, 2) {
+		t.Fatalf("published %v, want [1 2]", got)
+	}
+
+	outbox.commit(3)
+	waiting, err = listener.catchUp(context.Background(), listener.Broker.LastID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waiting {
+		t.Errorf("still waiting after the gap filled")
+	}
+	if got := received(sub); !equalIDs(got, 3, 4) {
+		t.Errorf("published %v, want [3 4]", got)
+	}
+}
+
+func TestListenerSkipsGapAfterTimeout(t *testing.T) {
+	listener, outbox, sub := newTestListener(t, 20*time.Millisecond, 1, 2, 3)
+	outbox.uncommitted[2] = true
+
+	waiting, err := listener.catchUp(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waiting {
+		t.Errorf("not waiting on the gap at 2")
+	}
+	if got := received(sub); !equalIDs(got, 1) {
+		t.Fatalf("published %v, want [1]", got)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	waiting, err = listener.catchUp(context.Background(), listener.Broker.LastID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waiting {
+		t.Errorf("still waiting after the gap timed out")
+	}
+	if got := received(sub); !equalIDs(got, 3) {
+		t.Errorf("published %v, want [3]", got)
+	}
+
+	// the rolled back id never shows up; a late commit is not sent out of order
+	outbox.commit(2)
+	_, err = listener.catchUp(context.Background(), listener.Broker.LastID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := received(sub); len(got) != 0 {
+		t.Errorf("published %v after the gap was skipped", got)
+	}
+}
+
+func TestListenerSeek(t *testing.T) {
+	listener, outbox, _ := newTestListener(t, time.Hour, 1, 2, 3)
+	outbox.uncommitted[3] = true
+
+	err := listener.seek(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listener.Broker.LastID() != 2 {
+		t.Errorf("LastID = %d, want 2", listener.Broker.LastID())
+	}
+}
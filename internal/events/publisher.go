@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"library-api-category/internal/models"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Message is the envelope delivered to subscribers for every outbox event.
+type Message struct {
+	ID            uint64          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uint64          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+func NewMessage(event *models.OutboxEvent) *Message {
+	return &Message{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.CreatedAt,
+		Data:          event.Payload,
+	}
+}
+
+// Publisher delivers a message to downstream consumers. Returning an error
+// leaves the event in the outbox so it is retried, so implementations must
+// tolerate receiving the same message more than once.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+type LogPublisher struct {
+}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (publisher *LogPublisher) Publish(ctx context.Context, msg *Message) error {
+	log.Printf("event %d %s %s:%d %s", msg.ID, msg.Type, msg.AggregateType, msg.AggregateID, msg.Data)
+	return nil
+}
+
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (publisher *WebhookPublisher) Publish(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, publisher.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", fmt.Sprint(msg.ID))
+	req.Header.Set("X-Event-Type", msg.Type)
+
+	resp, err := publisher.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MemoryPublisher keeps published messages in memory, for tests.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []*Message
+	err      error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (publisher *MemoryPublisher) Publish(ctx context.Context, msg *Message) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if publisher.err != nil {
+		return publisher.err
+	}
+	publisher.messages = append(publisher.messages, msg)
+	return nil
+}
+
+// FailWith makes every following Publish return err until it is called with nil.
+func (publisher *MemoryPublisher) FailWith(err error) {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	publisher.err = err
+}
+
+func (publisher *MemoryPublisher) Messages() []*Message {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	messages := make([]*Message, len(publisher.messages))
+	copy(messages, publisher.messages)
+	return messages
+}
+
+// MultiPublisher publishes to every publisher in order and stops at the first
+// error. Publishers that already succeeded will see the message again on retry.
+type MultiPublisher struct {
+	publishers []Publisher
+}
+
+func NewMultiPublisher(publishers ...Publisher) *MultiPublisher {
+	return &MultiPublisher{
+		publishers: publishers,
+	}
+}
+
+func (publisher *MultiPublisher) Publish(ctx context.Context, msg *Message) error {
+	for _, p := range publisher.publishers {
+		err := p.Publish(ctx, msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"library-api-category/internal/repositories"
+	"log"
+	"time"
+)
+
+// Relay moves committed outbox events to a Publisher. An event is only marked
+// as published after Publish succeeds, which gives at-least-once delivery. An
+// event that fails MaxAttempts times is marked dead so it no longer holds back
+// the events behind it.
+type Relay struct {
+	DB               *sql.DB
+	OutboxRepository repositories.OutboxRepository
+	Publisher        Publisher
+	Interval         time.Duration
+	BatchSize        int
+	MaxAttempts      int
+}
+
+func NewRelay(db *sql.DB, OutboxRepository repositories.OutboxRepository, publisher Publisher, interval time.Duration, batchSize int, maxAttempts int) *Relay {
+	return &Relay{
+		DB:               db,
+		OutboxRepository: OutboxRepository,
+		Publisher:        publisher,
+		Interval:         interval,
+		BatchSize:        batchSize,
+		MaxAttempts:      maxAttempts,
+	}
+}
+
+// Run relays events until ctx is cancelled. A full batch is followed
+// immediately by the next one so a backlog drains without waiting.
+func (relay *Relay) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		relayed, err := relay.RelayBatch(ctx)
+		if err != nil {
+			log.Printf("outbox relay: %v", err)
+		}
+
+		if err == nil && relayed == relay.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(relay.Interval)
+		}
+	}
+}
+
+// RelayBatch publishes up to BatchSize pending events in order and returns how
+// many were handled. It stops at the first failure so ordering is kept, unless
+// that failure was the event's last attempt and it is marked dead instead.
+func (relay *Relay) RelayBatch(ctx context.Context) (int, error) {
+	tx, err := relay.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	events, err := relay.OutboxRepository.FetchPendingEvents(ctx, tx, relay.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	relayed := 0
+	var publishErr error
+	for _, event := range events {
+		publishErr = relay.Publisher.Publish(ctx, NewMessage(event))
+		if publishErr != nil && event.Attempts+1 >= relay.MaxAttempts {
+			log.Printf("outbox relay: event %d is dead after %d attempts: %v", event.ID, event.Attempts+1, publishErr)
+			err = relay.OutboxRepository.MarkDead(ctx, tx, event.ID, publishErr.Error())
+			if err != nil {
+				return 0, err
+			}
+			publishErr = nil
+			relayed++
+			continue
+		}
+		if publishErr != nil {
+			err = relay.OutboxRepository.MarkFailed(ctx, tx, event.ID, publishErr.Error())
+			if err != nil {
+				return 0, err
+			}
+			break
+		}
+
+		err = relay.OutboxRepository.MarkPublished(ctx, tx, event.ID)
+		if err != nil {
+			return 0, err
+		}
+		relayed++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return relayed, publishErr
+}
@@ -0,0 +1,262 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/models"
+	"library-api-category/internal/repositories"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// memoryOutbox is an OutboxRepository over a slice. Events whose id is in
+// uncommitted are not visible yet, as in a transaction still open elsewhere.
+type memoryOutbox struct {
+	repositories.OutboxRepository
+	mu          sync.Mutex
+	events      []*models.OutboxEvent
+	uncommitted map[uint64]bool
+	published   map[uint64]bool
+	dead        map[uint64]bool
+	err         error
+}
+
+func newMemoryOutbox(ids ...uint64) *memoryOutbox {
+	outbox := &memoryOutbox{
+		uncommitted: make(map[uint64]bool),
+		published:   make(map[uint64]bool),
+		dead:        make(map[uint64]bool),
+	}
+	for _, id := range ids {
+		outbox.events = append(outbox.events, &models.OutboxEvent{
+			ID:            id,
+			EventType:     models.EventCategoryCreated,
+			AggregateType: models.AggregateCategory,
+			AggregateID:   id,
+			Payload:       []byte(`{}`),
+		})
+	}
+	return outbox
+}
+
+func (outbox *memoryOutbox) commit(id uint64) {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	delete(outbox.uncommitted, id)
+}
+
+func (outbox *memoryOutbox) visible() []*models.OutboxEvent {
+	var events []*models.OutboxEvent
+	for _, event := range outbox.events {
+		if !outbox.uncommitted[event.ID] {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events
+}
+
+func (outbox *memoryOutbox) ListEventsAfter(ctx context.Context, tx *sql.Tx, afterID uint64, limit int) ([]*models.OutboxEvent, error) {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	if outbox.err != nil {
+		return nil, outbox.err
+	}
+	var events []*models.OutboxEvent
+	for _, event := range outbox.visible() {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (outbox *memoryOutbox) LatestEventID(ctx context.Context, tx *sql.Tx) (uint64, error) {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	var latestID uint64
+	for _, event := range outbox.visible() {
+		latestID = event.ID
+	}
+	return latestID, outbox.err
+}
+
+func (outbox *memoryOutbox) FetchPendingEvents(ctx context.Context, tx *sql.Tx, limit int) ([]*models.OutboxEvent, error) {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	var events []*models.OutboxEvent
+	for _, event := range outbox.visible() {
+		if !outbox.published[event.ID] && !outbox.dead[event.ID] && len(events) < limit {
+			pending := *event
+			events = append(events, &pending)
+		}
+	}
+	return events, nil
+}
+
+func (outbox *memoryOutbox) MarkPublished(ctx context.Context, tx *sql.Tx, id uint64) error {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	outbox.published[id] = true
+	return nil
+}
+
+func (outbox *memoryOutbox) MarkFailed(ctx context.Context, tx *sql.Tx, id uint64, reason string) error {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	for _, event := range outbox.events {
+		if event.ID == id {
+			event.Attempts++
+		}
+	}
+	return nil
+}
+
+func (outbox *memoryOutbox) MarkDead(ctx context.Context, tx *sql.Tx, id uint64, reason string) error {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	outbox.dead[id] = true
+	return nil
+}
+
+func newTestDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func messageIDs(messages []*Message) []uint64 {
+	ids := make([]uint64, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+func equalIDs(got []uint64, want ...uint64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRelayRetriesInOrder(t *testing.T) {
+	db, mock := newTestDB(t)
+	outbox := newMemoryOutbox(1, 2, 3)
+	publisher := NewMemoryPublisher()
+	relay := NewRelay(db, outbox, publisher, 0, 10, 5)
+
+	failure := errors.New("broker down")
+	publisher.FailWith(failure)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	relayed, err := relay.RelayBatch(context.Background())
+	if !errors.Is(err, failure) || relayed != 0 {
+		t.Fatalf("RelayBatch = %d, %v; want 0, %v", relayed, err, failure)
+	}
+	if len(publisher.Messages()) != 0 {
+		t.Fatalf("events after a failed one were published: %v", messageIDs(publisher.Messages()))
+	}
+	if outbox.events[0].Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", outbox.events[0].Attempts)
+	}
+
+	publisher.FailWith(nil)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	relayed, err = relay.RelayBatch(context.Background())
+	if err != nil || relayed != 3 {
+		t.Fatalf("RelayBatch = %d, %v; want 3, nil", relayed, err)
+	}
+	if got := messageIDs(publisher.Messages()); !equalIDs(got, 1, 2, 3) {
+		t.Errorf("published %v, want [1 2 3]", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRelayMarksDeadAfterMaxAttempts(t *testing.T) {
+	db, mock := newTestDB(t)
+	outbox := newMemoryOutbox(1, 2)
+	outbox.events[0].Attempts = 2
+	publisher := NewMemoryPublisher()
+	relay := NewRelay(db, outbox, publisher, 0, 10, 3)
+
+	failure := errors.New("broker down")
+	publisher.FailWith(failure)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	relayed, err := relay.RelayBatch(context.Background())
+	if !errors.Is(err, failure) || relayed != 1 {
+		t.Fatalf("RelayBatch = %d, %v; want 1, %v", relayed, err, failure)
+	}
+	if !outbox.dead[1] {
+		t.Errorf("event 1 not dead after its last attempt")
+	}
+	if outbox.dead[2] || outbox.events[1].Attempts != 1 {
+		t.Errorf("event 2 dead = %v, attempts = %d; want a retry", outbox.dead[2], outbox.events[1].Attempts)
+	}
+
+	publisher.FailWith(nil)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	_, err = relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messageIDs(publisher.Messages()); !equalIDs(got, 2) {
+		t.Errorf("published %v, want [2]", got)
+	}
+}
+
+func TestRelayRollsBackOnRepositoryError(t *testing.T) {
+	db, mock := newTestDB(t)
+	outbox := &failingOutbox{memoryOutbox: newMemoryOutbox(1), err: errors.New("update failed")}
+	relay := NewRelay(db, outbox, NewMemoryPublisher(), 0, 10, 3)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err := relay.RelayBatch(context.Background())
+	if err == nil {
+		t.Fatal("RelayBatch succeeded")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type failingOutbox struct {
+	*memoryOutbox
+	err error
+}
+
+func (outbox *failingOutbox) MarkPublished(ctx context.Context, tx *sql.Tx, id uint64) error {
+	return outbox.err
+}
@@ -0,0 +1,243 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"library-api-category/internal/models"
+	"library-api-category/internal/repositories"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// SignWebhook returns the signature sent in X-Webhook-Signature. Receivers
+// recompute HMAC-SHA256 over "<timestamp>.<body>" with the subscription secret
+// and should reject timestamps that are too old to prevent replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SubscriptionPublisher fans a message out to the matching webhook
+// subscriptions by enqueueing one delivery per subscription.
+type SubscriptionPublisher struct {
+	DB                *sql.DB
+	WebhookRepository repositories.WebhookRepository
+}
+
+func NewSubscriptionPublisher(db *sql.DB, WebhookRepository repositories.WebhookRepository) *SubscriptionPublisher {
+	return &SubscriptionPublisher{
+		DB:                db,
+		WebhookRepository: WebhookRepository,
+	}
+}
+
+func (publisher *SubscriptionPublisher) Publish(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	tx, err := publisher.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = publisher.WebhookRepository.EnqueueDeliveries(ctx, tx, &models.WebhookDelivery{
+		EventID:   msg.ID,
+		EventType: msg.Type,
+		Payload:   body,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WebhookDispatcher sends pending webhook deliveries, retrying failures with
+// exponential backoff until MaxAttempts is reached, after which the delivery is
+// marked dead and only leaves the dead-letter list when redelivered by an admin.
+type WebhookDispatcher struct {
+	DB                *sql.DB
+	WebhookRepository repositories.WebhookRepository
+	Client            *http.Client
+	Interval          time.Duration
+	BatchSize         int
+	MaxAttempts       int
+	BackoffBase       time.Duration
+	BackoffMax        time.Duration
+}
+
+func NewWebhookDispatcher(db *sql.DB, WebhookRepository repositories.WebhookRepository, timeout time.Duration, interval time.Duration, maxAttempts int, backoffBase time.Duration, backoffMax time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		DB:                db,
+		WebhookRepository: WebhookRepository,
+		Client:            &http.Client{Timeout: timeout},
+		Interval:          interval,
+		BatchSize:         50,
+		MaxAttempts:       maxAttempts,
+		BackoffBase:       backoffBase,
+		BackoffMax:        backoffMax,
+	}
+}
+
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		dispatched, err := dispatcher.DispatchBatch(ctx)
+		if err != nil {
+			log.Printf("webhook dispatcher: %v", err)
+		}
+
+		if err == nil && dispatched == dispatcher.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(dispatcher.Interval)
+		}
+	}
+}
+
+// DispatchBatch attempts every due delivery once and returns how many were
+// attempted. Deliveries are claimed with a lease in a short transaction and
+// sent after it commits, so slow receivers never hold row locks or a
+// connection; the results are written in a second transaction.
+func (dispatcher *WebhookDispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	deliveries, err := dispatcher.claim(ctx)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		statusCode, sendErr := dispatcher.send(ctx, delivery)
+
+		now := time.Now()
+		delivery.Attempts++
+		delivery.LastStatusCode = statusCode
+		delivery.LastError = ""
+		if sendErr == nil {
+			delivery.Status = models.WebhookDeliveryDelivered
+			delivery.DeliveredAt = &now
+		} else {
+			delivery.LastError = sendErr.Error()
+			if delivery.Attempts >= dispatcher.MaxAttempts {
+				delivery.Status = models.WebhookDeliveryDead
+			} else {
+				delivery.NextAttemptAt = now.Add(dispatcher.backoff(delivery.Attempts))
+			}
+		}
+	}
+
+	err = dispatcher.record(ctx, deliveries)
+	if err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+// claim leases up to BatchSize due deliveries for long enough to send them all.
+func (dispatcher *WebhookDispatcher) claim(ctx context.Context) ([]*models.WebhookDelivery, error) {
+	tx, err := dispatcher.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deliveries, err := dispatcher.WebhookRepository.FetchDueDeliveries(ctx, tx, dispatcher.BatchSize)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint64, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.ID
+	}
+	lease := dispatcher.Client.Timeout*time.Duration(len(deliveries)) + dispatcher.Interval
+	err = dispatcher.WebhookRepository.LeaseDeliveries(ctx, tx, ids, time.Now().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, tx.Commit()
+}
+
+// record stores the outcome of every attempted delivery.
+func (dispatcher *WebhookDispatcher) record(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	tx, err := dispatcher.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, delivery := range deliveries {
+		err = dispatcher.WebhookRepository.UpdateDelivery(ctx, tx, delivery)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (dispatcher *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := dispatcher.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt, capped at BackoffMax.
+func (dispatcher *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := dispatcher.BackoffBase
+	for i := 1; i < attempts && wait < dispatcher.BackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > dispatcher.BackoffMax {
+		wait = dispatcher.BackoffMax
+	}
+	return wait
+}
@@ -0,0 +1,161 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"library-api-category/internal/models"
+	"library-api-category/internal/repositories"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type memoryWebhookRepository struct {
+	repositories.WebhookRepository
+	due     []*models.WebhookDelivery
+	leased  []uint64
+	updated []*models.WebhookDelivery
+}
+
+func (repository *memoryWebhookRepository) FetchDueDeliveries(ctx context.Context, tx *sql.Tx, limit int) ([]*models.WebhookDelivery, error) {
+	return repository.due, nil
+}
+
+func (repository *memoryWebhookRepository) LeaseDeliveries(ctx context.Context, tx *sql.Tx, ids []uint64, until time.Time) error {
+	repository.leased = append(repository.leased, ids...)
+	return nil
+}
+
+func (repository *memoryWebhookRepository) UpdateDelivery(ctx context.Context, tx *sql.Tx, delivery *models.WebhookDelivery) error {
+	repository.updated = append(repository.updated, delivery)
+	return nil
+}
+
+func TestWebhookDispatcherDispatchBatch(t *testing.T) {
+	payload := []byte(`{"id":1}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		if r.Header.Get(WebhookSignatureHeader) != SignWebhook("secret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		secret     string
+		attempts   int
+		wantStatus string
+		wantCode   int
+		wantRetry  bool
+	}{
+		{
+			name:       "delivered",
+			path:       "/ok",
+			secret:     "secret",
+			wantStatus: models.WebhookDeliveryDelivered,
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			name:       "wrong secret is retried",
+			path:       "/ok",
+			secret:     "other",
+			wantStatus: models.WebhookDeliveryPending,
+			wantCode:   http.StatusUnauthorized,
+			wantRetry:  true,
+		},
+		{
+			name:       "failure is retried",
+			path:       "/fail",
+			secret:     "secret",
+			attempts:   1,
+			wantStatus: models.WebhookDeliveryPending,
+			wantCode:   http.StatusInternalServerError,
+			wantRetry:  true,
+		},
+		{
+			name:       "last attempt is dead",
+			path:       "/fail",
+			secret:     "secret",
+			attempts:   2,
+			wantStatus: models.WebhookDeliveryDead,
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newTestDB(t)
+			mock.ExpectBegin()
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectCommit()
+
+			repository := &memoryWebhookRepository{due: []*models.WebhookDelivery{{
+				ID:        1,
+				EventID:   1,
+				EventType: models.EventCategoryCreated,
+				Payload:   payload,
+				Status:    models.WebhookDeliveryPending,
+				Attempts:  tt.attempts,
+				URL:       server.URL + tt.path,
+				Secret:    tt.secret,
+			}}}
+			dispatcher := NewWebhookDispatcher(db, repository, time.Second, time.Second, 3, time.Minute, time.Hour)
+
+			before := time.Now()
+			dispatched, err := dispatcher.DispatchBatch(context.Background())
+			if err != nil || dispatched != 1 {
+				t.Fatalf("DispatchBatch = %d, %v; want 1, nil", dispatched, err)
+			}
+			if !equalIDs(repository.leased, 1) {
+				t.Errorf("leased %v, want [1]", repository.leased)
+			}
+			if len(repository.updated) != 1 {
+				t.Fatalf("%d deliveries updated, want 1", len(repository.updated))
+			}
+
+			delivery := repository.updated[0]
+			if delivery.Status != tt.wantStatus || delivery.LastStatusCode != tt.wantCode {
+				t.Errorf("delivery = %s %d, want %s %d", delivery.Status, delivery.LastStatusCode, tt.wantStatus, tt.wantCode)
+			}
+			if delivery.Attempts != tt.attempts+1 {
+				t.Errorf("attempts = %d, want %d", delivery.Attempts, tt.attempts+1)
+			}
+			wantNext := before.Add(dispatcher.backoff(delivery.Attempts))
+			if tt.wantRetry && delivery.NextAttemptAt.Before(wantNext) {
+				t.Errorf("next attempt at %v, want after %v", delivery.NextAttemptAt, wantNext)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcherBackoff(t *testing.T) {
+	dispatcher := &WebhookDispatcher{BackoffBase: time.Second, BackoffMax: 5 * time.Second}
+
+	for attempts, want := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 5 * time.Second,
+		9: 5 * time.Second,
+	} {
+		if got := dispatcher.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
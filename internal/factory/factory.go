@@ -1,23 +1,365 @@
 package factory
 
 import (
+	"crypto/tls"
 	"database/sql"
+	"library-api-category/internal/cache"
+	"library-api-category/internal/config"
 	"library-api-category/internal/controllers"
+	"library-api-category/internal/events"
+	"library-api-category/internal/grpc/client"
+	"library-api-category/internal/grpc/server"
+	"library-api-category/internal/jobs"
+	"library-api-category/internal/policy"
+	"library-api-category/internal/ratelimit"
 	"library-api-category/internal/repositories"
 	"library-api-category/internal/services"
+	"library-api-category/pkg/database"
+	"library-api-category/pkg/tlsconfig"
+	"library-api-category/pkg/token"
+	"log"
+	"time"
 )
 
 type Provider struct {
-	CategoryProvider controllers.CategoryController
+	CategoryProvider  controllers.CategoryController
+	AuditProvider     controllers.AuditController
+	StatsProvider     controllers.CategoryStatsController
+	WebhookProvider   controllers.WebhookController
+	CacheProvider     controllers.CacheController
+	TokenProvider     controllers.TokenController
+	APIKeyProvider    controllers.APIKeyController
+	PolicyProvider    controllers.PolicyController
+	StreamProvider    controllers.StreamController
+	ReconcileProvider controllers.ReconciliationController
+	TokenValidator    client.TokenValidator
+	APIKeys           services.APIKeyService
+	Policy            *policy.Engine
+	RateLimiter       ratelimit.Limiter
+	RateLimitRules    *ratelimit.Rules
+	RateLimitPruner   *ratelimit.PostgresLimiter
+	CategoryServer    *server.CategoryServer
+	ServerTLS         *tlsconfig.Reloader
+	GRPCAuth          *server.APIKeyAuth
+	OutboxRelay       *events.Relay
+	WebhookDispatcher *events.WebhookDispatcher
+	EventListener     *events.Listener
+	CacheInvalidation *cache.InvalidationListener
+	TokenRevocations  *client.Revocations
+	OrphanReconciler  *jobs.OrphanReconciler
+	// TLSReloaders watch the certificate files of the auth and book clients
+	// and the gRPC server.
+	TLSReloaders []*tlsconfig.Reloader
 }
 
 func InitFactory(db *sql.DB) *Provider {
 
-	cateRepo := repositories.NewCategoryRepository()
-	cateService := services.NewCategoryService(db, cateRepo)
+	var cateRepo repositories.CategoryRepository = repositories.NewCategoryRepository()
+	var statsReporters []cache.StatsReporter
+	var invalidationListener *cache.InvalidationListener
+	if config.ENV.CacheEnabled {
+		categoryCache := cache.NewGenerationalCache(newCache())
+		cachedRepo := repositories.NewCachedCategoryRepository(db, cateRepo, categoryCache)
+		cateRepo = cachedRepo
+		statsReporters = append(statsReporters, cachedRepo)
+		invalidationListener = cache.NewInvalidationListener(database.DSN(), categoryCache)
+	}
+
+	authTLS := newAuthTLS()
+	bookTLS := newBookTLS()
+	serverTLS := newServerTLS()
+	var tlsReloaders []*tlsconfig.Reloader
+	for _, reloader := range []*tlsconfig.Reloader{authTLS, bookTLS, serverTLS} {
+		if reloader != nil {
+			tlsReloaders = append(tlsReloaders, reloader)
+		}
+	}
+
+	tokenValidator, cachedValidator := newTokenValidator(authTLS)
+	var tokenRevocations *client.Revocations
+	if cachedValidator != nil {
+		statsReporters = append(statsReporters, cachedValidator)
+		tokenRevocations = client.NewRevocations(database.DSN(), db, cachedValidator)
+	}
+	tokenController := controllers.NewTokenController(tokenRevocations)
+
+	policyEngine, err := policy.NewEngine(config.ENV.AuthPolicyFile)
+	if err != nil {
+		log.Fatalf("Failed to load authorization policy: %v", err)
+	}
+	policyController := controllers.NewPolicyController(policyEngine)
+	cacheController := controllers.NewCacheController(statsReporters...)
+	rateLimiter, rateLimitPruner := newRateLimiter(db)
+
+	apiKeyRepo := repositories.NewAPIKeyRepository()
+	apiKeyService := services.NewAPIKeyService(db, apiKeyRepo)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+
+	auditRepo := repositories.NewAuditLogRepository()
+	revisionRepo := repositories.NewCategoryRevisionRepository()
+	outboxRepo := repositories.NewOutboxRepository()
+	bookLookup := newBookLookup(bookTLS)
+	cateService := services.NewCategoryService(db, cateRepo, auditRepo, revisionRepo, outboxRepo, bookLookup, config.ENV.BookLookupMode == "strict", policyEngine)
 	cateController := controllers.NewCategoryController(cateService)
 
+	var orphanReconciler *jobs.OrphanReconciler
+	if bookLookup != nil {
+		orphanReconciler = jobs.NewOrphanReconciler(db, cateRepo, cateService, bookLookup,
+			config.ENV.OrphanReconcileInterval,
+			config.ENV.OrphanReconcilePageSize,
+			config.ENV.OrphanReconcileDryRun,
+		)
+	}
+	reconcileController := controllers.NewReconciliationController(orphanReconciler)
+
+	statsRepo := repositories.NewCategoryStatsRepository()
+	statsService := services.NewCategoryStatsService(db, statsRepo)
+	statsController := controllers.NewCategoryStatsController(statsService)
+
+	auditService := services.NewAuditService(db, auditRepo)
+	auditController := controllers.NewAuditController(auditService)
+
+	webhookRepo := repositories.NewWebhookRepository()
+	webhookService := services.NewWebhookService(db, webhookRepo)
+	webhookController := controllers.NewWebhookController(webhookService)
+
+	publisher := events.NewMultiPublisher(newPublisher(), events.NewSubscriptionPublisher(db, webhookRepo))
+	outboxRelay := events.NewRelay(db, outboxRepo, publisher, config.ENV.OutboxPollInterval, config.ENV.OutboxBatchSize, config.ENV.OutboxMaxAttempts)
+	webhookDispatcher := events.NewWebhookDispatcher(db, webhookRepo,
+		config.ENV.WebhookTimeout,
+		config.ENV.WebhookPollInterval,
+		config.ENV.WebhookMaxAttempts,
+		config.ENV.WebhookBackoffBase,
+		config.ENV.WebhookBackoffMax,
+	)
+
+	broker := events.NewBroker(config.ENV.StreamReplayBuffer, events.NewOutboxBacklog(db, outboxRepo))
+	eventListener := events.NewListener(database.DSN(), db, outboxRepo, broker, config.ENV.StreamGapTimeout)
+	streamController := controllers.NewStreamController(broker, config.ENV.StreamHeartbeat)
+	categoryServer := server.NewCategoryServer(cateService, broker)
+	grpcAuth := server.NewAPIKeyAuth(apiKeyService, config.ENV.GRPCRequireAPIKey)
+
 	return &Provider{
-		CategoryProvider: cateController,
+		CategoryProvider:  cateController,
+		AuditProvider:     auditController,
+		StatsProvider:     statsController,
+		WebhookProvider:   webhookController,
+		CacheProvider:     cacheController,
+		TokenProvider:     tokenController,
+		APIKeyProvider:    apiKeyController,
+		PolicyProvider:    policyController,
+		StreamProvider:    streamController,
+		ReconcileProvider: reconcileController,
+		TokenValidator:    tokenValidator,
+		APIKeys:           apiKeyService,
+		Policy:            policyEngine,
+		RateLimiter:       rateLimiter,
+		RateLimitRules:    newRateLimitRules(),
+		RateLimitPruner:   rateLimitPruner,
+		CategoryServer:    categoryServer,
+		ServerTLS:         serverTLS,
+		GRPCAuth:          grpcAuth,
+		OutboxRelay:       outboxRelay,
+		WebhookDispatcher: webhookDispatcher,
+		EventListener:     eventListener,
+		CacheInvalidation: invalidationListener,
+		TokenRevocations:  tokenRevocations,
+		OrphanReconciler:  orphanReconciler,
+		TLSReloaders:      tlsReloaders,
+	}
+}
+
+func newCache() cache.Cache {
+	switch config.ENV.CacheBackend {
+	case "redis":
+		return cache.NewRedisCache(config.ENV.CacheRedisAddr, config.ENV.CacheRedisPassword, config.ENV.CacheRedisDB, config.ENV.CacheTTL)
+	default:
+		return cache.NewMemoryCache(config.ENV.CacheMaxEntries, config.ENV.CacheTTL)
+	}
+}
+
+// newTokenValidator returns the validator used by the auth middleware and,
+// when AUTH_CACHE_ENABLED, the cache in front of it for revocations.
+// AUTH_MODE=local verifies tokens in process and only calls the user service
+// when AUTH_REMOTE_FALLBACK is set.
+func newTokenValidator(authTLS *tlsconfig.Reloader) (client.TokenValidator, *client.CachedTokenValidator) {
+	var tlsConfig *tls.Config
+	if authTLS != nil {
+		var err error
+		tlsConfig, err = authTLS.ClientConfig(config.ENV.AuthGRPCServerName, config.ENV.UserGRPC)
+		if err != nil {
+			log.Fatalf("Failed to configure auth client TLS: %v", err)
+		}
+	}
+
+	breaker := client.NewCircuitBreaker(config.ENV.AuthBreakerThreshold, config.ENV.AuthBreakerCooldown)
+	authClient, err := client.NewAuthClient(config.ENV.UserGRPC, tlsConfig,
+		config.ENV.AuthGRPCTimeout,
+		config.ENV.AuthGRPCMaxAttempts,
+		config.ENV.AuthGRPCBackoff,
+		breaker,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize auth client: %v", err)
+	}
+
+	var validator client.TokenValidator = authClient
+	if config.ENV.AuthMode == "local" {
+		verifier := newVerifier()
+		validator = verifier
+		if config.ENV.AuthRemoteFallback {
+			validator = client.NewFallbackTokenValidator(verifier, authClient)
+		}
+	}
+
+	if !config.ENV.AuthCacheEnabled {
+		return validator, nil
+	}
+	// a shared validation may take every attempt and the backoff between them
+	timeout := config.ENV.AuthGRPCTimeout*time.Duration(config.ENV.AuthGRPCMaxAttempts) +
+		config.ENV.AuthGRPCBackoff<<config.ENV.AuthGRPCMaxAttempts
+	cachedValidator := client.NewCachedTokenValidator(validator, config.ENV.AuthCacheMaxEntries, config.ENV.AuthCacheTTL, timeout)
+	return cachedValidator, cachedValidator
+}
+
+// newAuthTLS returns nil unless AUTH_GRPC_TLS is set, which keeps the
+// connection to the user service in plaintext.
+func newAuthTLS() *tlsconfig.Reloader {
+	if !config.ENV.AuthGRPCTLS {
+		return nil
+	}
+
+	reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+		CA:   config.ENV.AuthGRPCCAFile,
+		Cert: config.ENV.AuthGRPCCertFile,
+		Key:  config.ENV.AuthGRPCKeyFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load auth client certificates: %v", err)
+	}
+	return reloader
+}
+
+// newBookTLS returns nil unless BOOK_GRPC_TLS is set, which keeps the
+// connection to the book service in plaintext.
+func newBookTLS() *tlsconfig.Reloader {
+	if !config.ENV.BookGRPCTLS {
+		return nil
+	}
+
+	reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+		CA:   config.ENV.BookGRPCCAFile,
+		Cert: config.ENV.BookGRPCCertFile,
+		Key:  config.ENV.BookGRPCKeyFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load book client certificates: %v", err)
+	}
+	return reloader
+}
+
+// newServerTLS returns nil unless GRPC_TLS_CERT_FILE is set, which keeps the
+// gRPC server in plaintext.
+func newServerTLS() *tlsconfig.Reloader {
+	if config.ENV.GRPCTLSCertFile == "" {
+		return nil
+	}
+
+	reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+		CA:   config.ENV.GRPCTLSClientCAFile,
+		Cert: config.ENV.GRPCTLSCertFile,
+		Key:  config.ENV.GRPCTLSKeyFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load gRPC server certificates: %v", err)
+	}
+	return reloader
+}
+
+func newVerifier() *token.Verifier {
+	var jwks *token.JWKS
+	if config.ENV.AuthJWKS != "" {
+		var err error
+		jwks, err = token.NewJWKS(config.ENV.AuthJWKS, config.ENV.AuthJWKSRefresh)
+		if err != nil {
+			log.Fatalf("Failed to load JWKS: %v", err)
+		}
+	}
+
+	if config.ENV.AuthJWTSecret == "" && jwks == nil {
+		log.Fatal("AUTH_MODE=local needs AUTH_JWT_SECRET or AUTH_JWKS")
+	}
+	return token.NewVerifier(config.ENV.AuthJWTSecret, jwks, config.ENV.AuthJWTIssuer, config.ENV.AuthJWTAudience)
+}
+
+// newBookLookup returns nil when BOOK_GRPC is unset, which turns book
+// verification off.
+func newBookLookup(bookTLS *tlsconfig.Reloader) client.BookLookup {
+	if config.ENV.BookGRPC == "" {
+		return nil
+	}
+
+	var tlsConfig *tls.Config
+	if bookTLS != nil {
+		var err error
+		tlsConfig, err = bookTLS.ClientConfig(config.ENV.BookGRPCServerName, config.ENV.BookGRPC)
+		if err != nil {
+			log.Fatalf("Failed to configure book client TLS: %v", err)
+		}
+	}
+
+	bookClient, err := client.NewBookClient(config.ENV.BookGRPC, tlsConfig, config.ENV.BookLookupTimeout)
+	if err != nil {
+		log.Printf("book lookup disabled: %v", err)
+		return nil
+	}
+	return bookClient
+}
+
+// newRateLimiter returns a nil limiter when RATE_LIMIT_ENABLED is off, and
+// the Postgres limiter a second time so its buckets can be pruned.
+func newRateLimiter(db *sql.DB) (ratelimit.Limiter, *ratelimit.PostgresLimiter) {
+	if !config.ENV.RateLimitEnabled {
+		return nil, nil
+	}
+
+	switch config.ENV.RateLimitBackend {
+	case "postgres":
+		limiter := ratelimit.NewPostgresLimiter(db)
+		return limiter, limiter
+	default:
+		return ratelimit.NewMemoryLimiter(), nil
+	}
+}
+
+func newRateLimitRules() *ratelimit.Rules {
+	defaultLimit, err := ratelimit.ParseLimit(config.ENV.RateLimitDefault)
+	if err != nil {
+		log.Fatalf("Failed to parse RATE_LIMIT_DEFAULT: %v", err)
+	}
+	roles, err := ratelimit.ParseLimits(config.ENV.RateLimitRoles)
+	if err != nil {
+		log.Fatalf("Failed to parse RATE_LIMIT_ROLES: %v", err)
+	}
+	routes, err := ratelimit.ParseLimits(config.ENV.RateLimitRoutes)
+	if err != nil {
+		log.Fatalf("Failed to parse RATE_LIMIT_ROUTES: %v", err)
+	}
+
+	return &ratelimit.Rules{
+		Default: defaultLimit,
+		Roles:   roles,
+		Routes:  routes,
+	}
+}
+
+func newPublisher() events.Publisher {
+	switch config.ENV.OutboxPublisher {
+	case "webhook":
+		return events.NewWebhookPublisher(config.ENV.OutboxWebhookURL, 10*time.Second)
+	case "memory":
+		return events.NewMemoryPublisher()
+	default:
+		return events.NewLogPublisher()
 	}
 }
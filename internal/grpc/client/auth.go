@@ -2,42 +2,129 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
 
 	tkn "library-api-category/pkg/token"
 	pb "library-api-category/proto/auth"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// ErrAuthUnavailable wraps every failure to get an answer from the user
+// service, as opposed to the service rejecting the token.
+var ErrAuthUnavailable = errors.New("auth service is unavailable")
+
 type AuthClient struct {
-	client pb.AuthServiceClient
-	conn   *grpc.ClientConn
+	client      pb.AuthServiceClient
+	conn        *grpc.ClientConn
+	timeout     time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	breaker     *CircuitBreaker
 }
 
-func NewAuthClient(addr string) (*AuthClient, error) {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewAuthClient dials the user service, over TLS when tlsConfig is set. Each
+// attempt of a call gets timeout; transient failures are tried up to
+// maxAttempts times with exponential backoff starting at backoff. A nil
+// breaker disables circuit breaking.
+func NewAuthClient(addr string, tlsConfig *tls.Config, timeout time.Duration, maxAttempts int, backoff time.Duration, breaker *CircuitBreaker) (*AuthClient, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(transportCredentials(tlsConfig)))
 	if err != nil {
 		return nil, err
 	}
 
 	client := pb.NewAuthServiceClient(conn)
 	return &AuthClient{
-		client: client,
-		conn:   conn,
+		client:      client,
+		conn:        conn,
+		timeout:     timeout,
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     backoff,
+		breaker:     breaker,
 	}, nil
 }
 
-func (c *AuthClient) ValidateToken(ctx context.Context, token string) (bool, *tkn.Token) {
-	resp, err := c.client.ValidateToken(ctx, &pb.ValidateRequest{Token: token})
-	if err != nil || !resp.Success {
-		return false, nil
+func (c *AuthClient) ValidateToken(ctx context.Context, token string) (*tkn.Token, error) {
+	err := c.breaker.Allow()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
 	}
 
-	return true, &tkn.Token{
+	resp, err := c.validate(ctx, token)
+	switch {
+	case err == nil || errors.Is(err, tkn.ErrInvalidToken):
+		c.breaker.Success()
+	case ctx.Err() != nil:
+		// the caller gave up, which says nothing about the user service
+		c.breaker.Cancel()
+	default:
+		c.breaker.Failure()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tkn.Token{
 		AuthId: int(resp.AuthId),
 		Role:   resp.Role,
+	}, nil
+}
+
+func (c *AuthClient) validate(ctx context.Context, token string) (*pb.ValidateResponse, error) {
+	wait := c.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.call(ctx, token)
+		switch status.Code(err) {
+		case codes.OK:
+			if !resp.Success {
+				return nil, tkn.ErrInvalidToken
+			}
+			return resp, nil
+		case codes.Unauthenticated, codes.InvalidArgument, codes.PermissionDenied, codes.NotFound:
+			return nil, tkn.ErrInvalidToken
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+			if attempt < c.maxAttempts && ctx.Err() == nil {
+				break
+			}
+			fallthrough
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+		}
+
+		// full jitter keeps replicas from retrying in lockstep
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(wait) + 1)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, ctx.Err())
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+func (c *AuthClient) call(ctx context.Context, token string) (*pb.ValidateResponse, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	return c.client.ValidateToken(ctx, &pb.ValidateRequest{Token: token})
+}
+
+func transportCredentials(tlsConfig *tls.Config) credentials.TransportCredentials {
+	if tlsConfig == nil {
+		return insecure.NewCredentials()
 	}
+	return credentials.NewTLS(tlsConfig)
 }
 
 func (c *AuthClient) Close() {
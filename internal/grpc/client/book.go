@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"library-api-category/internal/models"
+	"time"
+
+	pb "library-api-category/proto/book"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrBookNotFound is returned by a BookLookup when the book does not exist.
+// Any other error means the book service could not answer.
+var ErrBookNotFound = errors.New("book is not found")
+
+// BookLookup resolves books owned by library-api-book.
+type BookLookup interface {
+	GetBook(ctx context.Context, id uint64) (*models.Book, error)
+}
+
+type BookClient struct {
+	client  pb.BookServiceClient
+	conn    *grpc.ClientConn
+	timeout time.Duration
+}
+
+// NewBookClient dials the book service, over TLS when tlsConfig is set.
+func NewBookClient(addr string, tlsConfig *tls.Config, timeout time.Duration) (*BookClient, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(transportCredentials(tlsConfig)))
+	if err != nil {
+		return nil, err
+	}
+
+	client := pb.NewBookServiceClient(conn)
+	return &BookClient{
+		client:  client,
+		conn:    conn,
+		timeout: timeout,
+	}, nil
+}
+
+func (c *BookClient) GetBook(ctx context.Context, id uint64) (*models.Book, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetBook(ctx, &pb.GetBookRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrBookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Book{
+		ID:        resp.Id,
+		AuthorID:  resp.AuthorId,
+		Title:     resp.Title,
+		Stock:     resp.Stock,
+		PublishAt: time.Unix(resp.PublishAt, 0),
+		UpdatedAt: time.Unix(resp.UpdatedAt, 0),
+	}, nil
+}
+
+func (c *BookClient) Close() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"library-api-category/internal/models"
+	"sync"
+)
+
+// FakeBookLookup is an in-memory BookLookup for tests and local runs without
+// the book service.
+type FakeBookLookup struct {
+	mu    sync.Mutex
+	books map[uint64]*models.Book
+	err   error
+}
+
+func NewFakeBookLookup(books ...*models.Book) *FakeBookLookup {
+	fake := &FakeBookLookup{books: make(map[uint64]*models.Book)}
+	for _, book := range books {
+		fake.books[book.ID] = book
+	}
+	return fake
+}
+
+func (fake *FakeBookLookup) GetBook(ctx context.Context, id uint64) (*models.Book, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if fake.err != nil {
+		return nil, fake.err
+	}
+	book, ok := fake.books[id]
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	return book, nil
+}
+
+func (fake *FakeBookLookup) AddBook(book *models.Book) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	fake.books[book.ID] = book
+}
+
+func (fake *FakeBookLookup) RemoveBook(id uint64) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	delete(fake.books, id)
+}
+
+// FailWith makes every lookup fail with err, as if the book service were
+// down. A nil err restores normal behaviour.
+func (fake *FakeBookLookup) FailWith(err error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	fake.err = err
+}
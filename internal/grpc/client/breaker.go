@@ -0,0 +1,95 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while a CircuitBreaker rejects calls.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker stops calls to a dependency after threshold consecutive
+// failures. Once cooldown has passed a single trial call is let through: its
+// success closes the breaker, its failure opens it for another cooldown.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker returns nil when threshold is not positive, a nil
+// breaker lets every call through.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow returns ErrCircuitOpen when the call must not be made. Every allowed
+// call must be followed by Success, Failure or Cancel.
+func (breaker *CircuitBreaker) Allow() error {
+	if breaker == nil {
+		return nil
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	if breaker.failures < breaker.threshold {
+		return nil
+	}
+	if breaker.probing || time.Now().Before(breaker.openUntil) {
+		return ErrCircuitOpen
+	}
+	breaker.probing = true
+	return nil
+}
+
+func (breaker *CircuitBreaker) Success() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.failures = 0
+	breaker.probing = false
+}
+
+func (breaker *CircuitBreaker) Failure() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.failures++
+	breaker.probing = false
+	if breaker.failures >= breaker.threshold {
+		breaker.openUntil = time.Now().Add(breaker.cooldown)
+	}
+}
+
+// Cancel ends an allowed call the caller abandoned, without counting it
+// either way.
+func (breaker *CircuitBreaker) Cancel() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.probing = false
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+type breakerStep struct {
+	op   string
+	want error
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	allowed := breakerStep{op: "allow"}
+	rejected := breakerStep{op: "allow", want: ErrCircuitOpen}
+	success := breakerStep{op: "success"}
+	failure := breakerStep{op: "failure"}
+	cancel := breakerStep{op: "cancel"}
+	cooldown := breakerStep{op: "cooldown"}
+
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{
+			name:  "closed below the threshold",
+			steps: []breakerStep{allowed, failure, allowed, failure, allowed},
+		},
+		{
+			name:  "success resets the count",
+			steps: []breakerStep{allowed, failure, allowed, failure, allowed, success, allowed, failure, allowed},
+		},
+		{
+			name:  "opens at the threshold",
+			steps: []breakerStep{allowed, failure, allowed, failure, allowed, failure, rejected, rejected},
+		},
+		{
+			name: "half-open after the cooldown lets one trial through",
+			steps: []breakerStep{
+				allowed, failure, allowed, failure, allowed, failure,
+				cooldown, allowed, rejected,
+			},
+		},
+		{
+			name: "successful trial closes",
+			steps: []breakerStep{
+				allowed, failure, allowed, failure, allowed, failure,
+				cooldown, allowed, success, allowed, failure, allowed,
+			},
+		},
+		{
+			name: "failed trial opens for another cooldown",
+			steps: []breakerStep{
+				allowed, failure, allowed, failure, allowed, failure,
+				cooldown, allowed, failure, rejected, cooldown, allowed,
+			},
+		},
+		{
+			name: "cancelled trial lets the next one through",
+			steps: []breakerStep{
+				allowed, failure, allowed, failure, allowed, failure,
+				cooldown, allowed, cancel, allowed, rejected,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(3, testCooldown)
+
+			for i, step := range tt.steps {
+				switch step.op {
+				case "allow":
+					if err := breaker.Allow(); !errors.Is(err, step.want) {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, err, step.want)
+					}
+				case "success":
+					breaker.Success()
+				case "failure":
+					breaker.Failure()
+				case "cancel":
+					breaker.Cancel()
+				case "cooldown":
+					time.Sleep(testCooldown + 5*time.Millisecond)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	breaker := NewCircuitBreaker(0, testCooldown)
+	if breaker != nil {
+		t.Fatalf("NewCircuitBreaker(0) = %v, want nil", breaker)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("nil breaker Allow() = %v", err)
+		}
+		breaker.Failure()
+	}
+	breaker.Success()
+	breaker.Cancel()
+}
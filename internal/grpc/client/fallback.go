@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tkn "library-api-category/pkg/token"
+)
+
+// FallbackTokenValidator verifies tokens locally and asks the user service
+// only about tokens it has no key for, such as tokens signed by a key that
+// is not published yet. Tokens that fail local verification are rejected
+// without a remote call.
+type FallbackTokenValidator struct {
+	Local  *tkn.Verifier
+	Remote TokenValidator
+}
+
+func NewFallbackTokenValidator(local *tkn.Verifier, remote TokenValidator) *FallbackTokenValidator {
+	return &FallbackTokenValidator{
+		Local:  local,
+		Remote: remote,
+	}
+}
+
+func (v *FallbackTokenValidator) ValidateToken(ctx context.Context, token string) (*tkn.Token, error) {
+	payload, err := v.Local.Verify(token)
+	if errors.Is(err, tkn.ErrNoVerificationKey) {
+		return v.Remote.ValidateToken(ctx, token)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", tkn.ErrInvalidToken, err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tkn "library-api-category/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type stubRemoteValidator struct {
+	calls int
+	err   error
+}
+
+func (v *stubRemoteValidator) ValidateToken(ctx context.Context, token string) (*tkn.Token, error) {
+	v.calls++
+	if v.err != nil {
+		return nil, v.err
+	}
+	return &tkn.Token{AuthId: 9, Role: "remote"}, nil
+}
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestFallbackTokenValidator(t *testing.T) {
+	valid := jwt.MapClaims{"sub": "1", "role": "user", "exp": time.Now().Add(time.Hour).Unix()}
+	expired := jwt.MapClaims{"sub": "1", "role": "user", "exp": time.Now().Add(-time.Hour).Unix()}
+	unavailable := errors.New("user service unavailable")
+
+	tests := []struct {
+		name        string
+		localSecret string
+		token       func(t *testing.T) string
+		remoteErr   error
+		wantAuthID  int
+		wantInvalid bool
+		wantErr     error
+		wantRemote  int
+	}{
+		{
+			name:        "verified locally",
+			localSecret: "secret",
+			token:       func(t *testing.T) string { return signHS256(t, "secret", valid) },
+			wantAuthID:  1,
+		},
+		{
+			name:        "bad signature is rejected without a remote call",
+			localSecret: "secret",
+			token:       func(t *testing.T) string { return signHS256(t, "other", valid) },
+			wantInvalid: true,
+		},
+		{
+			name:        "expired token is rejected without a remote call",
+			localSecret: "secret",
+			token:       func(t *testing.T) string { return signHS256(t, "secret", expired) },
+			wantInvalid: true,
+		},
+		{
+			name:       "no local key falls back to the user service",
+			token:      func(t *testing.T) string { return signHS256(t, "secret", valid) },
+			wantAuthID: 9,
+			wantRemote: 1,
+		},
+		{
+			name:       "fallback failure is returned as is",
+			token:      func(t *testing.T) string { return signHS256(t, "secret", valid) },
+			remoteErr:  unavailable,
+			wantErr:    unavailable,
+			wantRemote: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &stubRemoteValidator{err: tt.remoteErr}
+			validator := NewFallbackTokenValidator(tkn.NewVerifier(tt.localSecret, nil, "", ""), remote)
+
+			payload, err := validator.ValidateToken(context.Background(), tt.token(t))
+			switch {
+			case tt.wantInvalid:
+				if !errors.Is(err, tkn.ErrInvalidToken) {
+					t.Errorf("err = %v, want ErrInvalidToken", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) || errors.Is(err, tkn.ErrInvalidToken) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("ValidateToken: %v", err)
+				}
+				if payload.AuthId != tt.wantAuthID {
+					t.Errorf("AuthId = %d, want %d", payload.AuthId, tt.wantAuthID)
+				}
+			}
+			if remote.calls != tt.wantRemote {
+				t.Errorf("remote calls = %d, want %d", remote.calls, tt.wantRemote)
+			}
+		})
+	}
+}
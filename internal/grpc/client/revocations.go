@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RevocationChannel is the Postgres NOTIFY channel carrying token revocations
+// to every replica.
+const RevocationChannel = "token_revocations"
+
+// Revocation names the cached tokens to drop: the token whose SHA-256 is
+// TokenHash, every token of AuthID, or both. The token itself never leaves the
+// replica that received it.
+type Revocation struct {
+	TokenHash string `json:"token_hash,omitempty"`
+	AuthID    int    `json:"auth_id,omitempty"`
+}
+
+// NewRevocation returns the revocation of token and of every token of authID,
+// either of which may be empty.
+func NewRevocation(token string, authID int) Revocation {
+	revocation := Revocation{AuthID: authID}
+	if token != "" {
+		revocation.TokenHash = tokenKey(token)
+	}
+	return revocation
+}
+
+// Revocations applies token revocations to the validation cache of every
+// replica: Publish sends them through Postgres NOTIFY and Run applies the ones
+// sent by any replica, including this one.
+type Revocations struct {
+	DSN       string
+	DB        *sql.DB
+	Validator *CachedTokenValidator
+}
+
+func NewRevocations(dsn string, db *sql.DB, validator *CachedTokenValidator) *Revocations {
+	return &Revocations{
+		DSN:       dsn,
+		DB:        db,
+		Validator: validator,
+	}
+}
+
+// Publish applies revocation to this replica right away and notifies the others.
+func (revocations *Revocations) Publish(ctx context.Context, revocation Revocation) error {
+	revocations.apply(revocation)
+
+	payload, err := json.Marshal(revocation)
+	if err != nil {
+		return err
+	}
+
+	_, err = revocations.DB.ExecContext(ctx, `SELECT pg_notify($1, $2)`, RevocationChannel, string(payload))
+	if err != nil {
+		return fmt.Errorf("notify token revocation: %w", err)
+	}
+	return nil
+}
+
+func (revocations *Revocations) Run(ctx context.Context) {
+	pqListener := pq.NewListener(revocations.DSN, time.Second, 30*time.Second, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("token revocation listener: %v", err)
+		}
+	})
+	defer pqListener.Close()
+
+	err := pqListener.Listen(RevocationChannel)
+	if err != nil {
+		log.Printf("token revocation listener: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-pqListener.Notify:
+			err = revocations.receive(notification)
+			if err != nil {
+				log.Printf("token revocation listener: %v", err)
+			}
+		case <-time.After(90 * time.Second):
+			go pqListener.Ping()
+		}
+	}
+}
+
+// receive applies the revocation carried by notification.
+func (revocations *Revocations) receive(notification *pq.Notification) error {
+	if notification == nil {
+		// reconnected, revocations may have been missed
+		revocations.Validator.RevokeAll()
+		return nil
+	}
+
+	var revocation Revocation
+	err := json.Unmarshal([]byte(notification.Extra), &revocation)
+	if err != nil {
+		return fmt.Errorf("decode token revocation: %w", err)
+	}
+	revocations.apply(revocation)
+	return nil
+}
+
+func (revocations *Revocations) apply(revocation Revocation) {
+	if revocation.TokenHash != "" {
+		revocations.Validator.RevokeKey(revocation.TokenHash)
+	}
+	if revocation.AuthID != 0 {
+		revocations.Validator.RevokeAuthID(revocation.AuthID)
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tkn "library-api-category/pkg/token"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+type countingValidator struct {
+	calls int
+}
+
+func (v *countingValidator) ValidateToken(ctx context.Context, token string) (*tkn.Token, error) {
+	v.calls++
+	return &tkn.Token{AuthId: 7, Role: "user"}, nil
+}
+
+func newTestRevocations(t *testing.T) (*Revocations, *countingValidator, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	validator := &countingValidator{}
+	cached := NewCachedTokenValidator(validator, 10, time.Minute, time.Second)
+	return NewRevocations("", db, cached), validator, mock
+}
+
+func validate(t *testing.T, revocations *Revocations, token string) {
+	t.Helper()
+	_, err := revocations.Validator.ValidateToken(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevocationsReceive(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification func(t *testing.T) *pq.Notification
+		wantCalls    int
+	}{
+		{
+			name: "token",
+			notification: func(t *testing.T) *pq.Notification {
+				return notificationOf(t, NewRevocation("token-a", 0))
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "auth id",
+			notification: func(t *testing.T) *pq.Notification {
+				return notificationOf(t, NewRevocation("", 7))
+			},
+			wantCalls: 4,
+		},
+		{
+			name: "other user",
+			notification: func(t *testing.T) *pq.Notification {
+				return notificationOf(t, NewRevocation("", 8))
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "reconnected",
+			notification: func(t *testing.T) *pq.Notification {
+				return nil
+			},
+			wantCalls: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revocations, validator, _ := newTestRevocations(t)
+			validate(t, revocations, "token-a")
+			validate(t, revocations, "token-b")
+
+			err := revocations.receive(tt.notification(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validate(t, revocations, "token-a")
+			validate(t, revocations, "token-b")
+			if validator.calls != tt.wantCalls {
+				t.Errorf("validations = %d, want %d", validator.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRevocationsReceiveMalformed(t *testing.T) {
+	revocations, _, _ := newTestRevocations(t)
+
+	err := revocations.receive(&pq.Notification{Channel: RevocationChannel, Extra: "{"})
+	if err == nil {
+		t.Fatal("malformed revocation accepted")
+	}
+}
+
+func TestRevocationsPublish(t *testing.T) {
+	revocations, validator, mock := newTestRevocations(t)
+	validate(t, revocations, "token-a")
+
+	revocation := NewRevocation("token-a", 0)
+	payload, _ := json.Marshal(revocation)
+	mock.ExpectExec(`SELECT pg_notify`).
+		WithArgs(RevocationChannel, string(payload)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := revocations.Publish(context.Background(), revocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if strings.Contains(string(payload), "token-a") {
+		t.Errorf("payload carries the token: %s", payload)
+	}
+
+	validate(t, revocations, "token-a")
+	if validator.calls != 2 {
+		t.Errorf("validations = %d, want 2: token still cached on the publishing replica", validator.calls)
+	}
+}
+
+func notificationOf(t *testing.T, revocation Revocation) *pq.Notification {
+	t.Helper()
+	payload, err := json.Marshal(revocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pq.Notification{Channel: RevocationChannel, Extra: string(payload)}
+}
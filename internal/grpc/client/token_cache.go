@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"library-api-category/internal/cache"
+	"sync/atomic"
+	"time"
+
+	tkn "library-api-category/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// TokenValidator validates bearer tokens, implemented by AuthClient. Rejected
+// tokens fail with tkn.ErrInvalidToken; any other error means the token could
+// not be checked.
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*tkn.Token, error)
+}
+
+// CachedTokenValidator remembers successful validations so repeated requests
+// from one session skip the round trip to the user service. Entries are keyed
+// by a SHA-256 of the token, live for at most ttl and never past the token's
+// own expiry. Failed validations are not cached.
+//
+// Concurrent validations of one token share a single call. That call runs on
+// a context detached from the callers, bounded by timeout, so one caller
+// giving up does not fail the others; each caller still returns as soon as
+// its own context is done.
+type CachedTokenValidator struct {
+	validator TokenValidator
+	cache     *cache.LRU[string, tkn.Token]
+	ttl       time.Duration
+	timeout   time.Duration
+	group     singleflight.Group
+	// generation is bumped by every revocation so a validation that started
+	// before it is not cached afterwards.
+	generation atomic.Uint64
+}
+
+func NewCachedTokenValidator(validator TokenValidator, maxEntries int, ttl time.Duration, timeout time.Duration) *CachedTokenValidator {
+	return &CachedTokenValidator{
+		validator: validator,
+		cache:     cache.NewLRU[string, tkn.Token](maxEntries, ttl),
+		ttl:       ttl,
+		timeout:   timeout,
+	}
+}
+
+func (v *CachedTokenValidator) ValidateToken(ctx context.Context, token string) (*tkn.Token, error) {
+	key := tokenKey(token)
+	if payload, ok := v.cache.Get(key); ok {
+		return &payload, nil
+	}
+
+	shared := v.group.DoChan(key, func() (interface{}, error) {
+		generation := v.generation.Load()
+
+		sharedCtx := context.WithoutCancel(ctx)
+		if v.timeout > 0 {
+			var cancel context.CancelFunc
+			sharedCtx, cancel = context.WithTimeout(sharedCtx, v.timeout)
+			defer cancel()
+		}
+
+		payload, err := v.validator.ValidateToken(sharedCtx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl := v.ttl
+		if expiry := tokenExpiry(token); !expiry.IsZero() && time.Until(expiry) < ttl {
+			ttl = time.Until(expiry)
+		}
+		if ttl > 0 && v.generation.Load() == generation {
+			v.cache.SetWithTTL(key, *payload, ttl)
+		}
+		return *payload, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, ctx.Err())
+	case result := <-shared:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		payload := result.Val.(tkn.Token)
+		return &payload, nil
+	}
+}
+
+// RevokeKey drops the cached token whose SHA-256 is key, as sent to other
+// replicas in place of the token itself, so its next use is validated again.
+func (v *CachedTokenValidator) RevokeKey(key string) {
+	v.generation.Add(1)
+	v.cache.Delete(key)
+}
+
+// RevokeAuthID drops every cached token of a user.
+func (v *CachedTokenValidator) RevokeAuthID(authID int) {
+	v.generation.Add(1)
+	v.cache.DeleteWhere(func(_ string, payload tkn.Token) bool {
+		return payload.AuthId == authID
+	})
+}
+
+// RevokeAll drops every cached token.
+func (v *CachedTokenValidator) RevokeAll() {
+	v.generation.Add(1)
+	v.cache.Purge()
+}
+
+func (v *CachedTokenValidator) CacheStats() map[string]cache.Stats {
+	stats := v.cache.Stats()
+	stats.Backend = "memory"
+	return map[string]cache.Stats{
+		"tokens": stats,
+	}
+}
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenExpiry reads the expiry of a JWT without verifying it, from the
+// standard exp claim or the payload written by pkg/token. It returns the zero
+// time when there is none.
+func tokenExpiry(token string) time.Time {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return time.Time{}
+	}
+
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		return exp.Time
+	}
+
+	payloadByte, err := json.Marshal(claims["payload"])
+	if err != nil {
+		return time.Time{}
+	}
+	var payload tkn.Token
+	if json.Unmarshal(payloadByte, &payload) != nil {
+		return time.Time{}
+	}
+	return payload.Expired
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"library-api-category/internal/policy"
+	"library-api-category/internal/services"
+	pb "library-api-category/proto/category"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodPermissions is the permission an API key needs for each RPC. Methods
+// missing here are refused to API keys.
+var methodPermissions = map[string]string{
+	pb.CategoryService_ListBookCategories_FullMethodName:      policy.CategoryRead,
+	pb.CategoryService_BatchListBookCategories_FullMethodName: policy.CategoryRead,
+	pb.CategoryService_BatchGetCategories_FullMethodName:      policy.CategoryRead,
+	pb.CategoryService_WatchCategories_FullMethodName:         policy.CategoryRead,
+}
+
+// APIKeyAuth checks the x-api-key metadata of incoming calls. A key that is
+// present must be valid and hold the method's permission. Calls without a key
+// are let through unless Required is set.
+type APIKeyAuth struct {
+	APIKeys  services.APIKeyService
+	Required bool
+}
+
+func NewAPIKeyAuth(APIKeys services.APIKeyService, Required bool) *APIKeyAuth {
+	return &APIKeyAuth{
+		APIKeys:  APIKeys,
+		Required: Required,
+	}
+}
+
+func (auth *APIKeyAuth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		err := auth.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (auth *APIKeyAuth) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := auth.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func (auth *APIKeyAuth) authorize(ctx context.Context, method string) error {
+	var secret string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			secret = values[0]
+		}
+	}
+
+	if secret == "" {
+		if auth.Required {
+			return status.Error(codes.Unauthenticated, "x-api-key is required")
+		}
+		return nil
+	}
+
+	apiKey, custErr := auth.APIKeys.AuthenticateAPIKey(ctx, secret)
+	if custErr != nil {
+		return toStatusError(custErr)
+	}
+
+	permission, ok := methodPermissions[method]
+	if !ok || !slices.Contains(apiKey.Permissions, permission) {
+		return status.Error(codes.PermissionDenied, "api key doesn't have permission to call "+method)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/events"
+	"library-api-category/internal/models"
+	"library-api-category/internal/params"
+	"library-api-category/internal/services"
+	pb "library-api-category/proto/category"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type CategoryServer struct {
+	pb.UnimplementedCategoryServiceServer
+	CategoryService services.CategoryService
+	Broker          *events.Broker
+}
+
+func NewCategoryServer(CategoryService services.CategoryService, broker *events.Broker) *CategoryServer {
+	return &CategoryServer{
+		CategoryService: CategoryService,
+		Broker:          broker,
+	}
+}
+
+func (server *CategoryServer) ListBookCategories(ctx context.Context, req *pb.BookCategoriesRequest) (*pb.BookCategoriesResponse, error) {
+	categories, custErr := server.CategoryService.ListCategoryOfBook(ctx, req.BookId)
+	if custErr != nil {
+		return &pb.BookCategoriesResponse{Success: false}, nil
+	}
+
+	names := make([]string, len(categories))
+	for i, cate := range categories {
+		names[i] = cate.Name
+	}
+
+	return &pb.BookCategoriesResponse{
+		Success: true,
+		CatName: names,
+	}, nil
+}
+
+func (server *CategoryServer) BatchListBookCategories(ctx context.Context, req *pb.BatchBookCategoriesRequest) (*pb.BatchBookCategoriesResponse, error) {
+	books, custErr := server.CategoryService.ListCategoriesOfBooks(ctx, req.BookIds)
+	if custErr != nil {
+		return nil, toStatusError(custErr)
+	}
+
+	resp := &pb.BatchBookCategoriesResponse{Books: make(map[uint64]*pb.CategoryList, len(books))}
+	for bookID, categories := range books {
+		list := &pb.CategoryList{Categories: make([]*pb.Category, len(categories))}
+		for i, cate := range categories {
+			list.Categories[i] = toCategory(cate)
+		}
+		resp.Books[bookID] = list
+	}
+
+	return resp, nil
+}
+
+func (server *CategoryServer) BatchGetCategories(ctx context.Context, req *pb.BatchGetCategoriesRequest) (*pb.BatchGetCategoriesResponse, error) {
+	result, custErr := server.CategoryService.BatchGetCategories(ctx, req.Ids)
+	if custErr != nil {
+		return nil, toStatusError(custErr)
+	}
+
+	resp := &pb.BatchGetCategoriesResponse{
+		Categories: make([]*pb.Category, len(result.Categories)),
+		MissingIds: result.MissingIDs,
+	}
+	for i, cate := range result.Categories {
+		resp.Categories[i] = toCategory(cate)
+	}
+
+	return resp, nil
+}
+
+func (server *CategoryServer) WatchCategories(req *pb.WatchCategoriesRequest, stream grpc.ServerStreamingServer[pb.CategoryEvent]) error {
+	sub, replay, complete := server.Broker.Subscribe(stream.Context(), req.AfterRevision)
+	defer sub.Close()
+
+	if !complete {
+		err := stream.Send(&pb.CategoryEvent{Type: "Reset"})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range replay {
+		err := stream.Send(toCategoryEvent(msg))
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "watcher fell behind, resume from the last received revision")
+			}
+			err := stream.Send(toCategoryEvent(msg))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toCategoryEvent(msg *events.Message) *pb.CategoryEvent {
+	event := &pb.CategoryEvent{
+		Revision:   msg.ID,
+		Type:       msg.Type,
+		OccurredAt: msg.OccurredAt.Unix(),
+	}
+
+	switch msg.AggregateType {
+	case models.AggregateCategory:
+		var cate params.CategoryResponse
+		if json.Unmarshal(msg.Data, &cate) == nil {
+			event.Category = &pb.Category{
+				Id:          cate.ID,
+				Name:        cate.Name,
+				Description: cate.Description,
+			}
+		}
+	case models.AggregateBook:
+		var bookCate params.BookCategoryRequest
+		if json.Unmarshal(msg.Data, &bookCate) == nil {
+			event.BookCategory = &pb.BookCategory{
+				BookId:     bookCate.BookID,
+				CategoryId: bookCate.CategoryID,
+			}
+		}
+	}
+
+	return event
+}
+
+func toCategory(cate *params.CategoryResponse) *pb.Category {
+	return &pb.Category{
+		Id:          cate.ID,
+		Name:        cate.Name,
+		Description: cate.Description,
+	}
+}
+
+// toStatusError maps a service error onto the closest gRPC status code.
+func toStatusError(custErr *response.CustomError) error {
+	code := codes.Internal
+	switch custErr.StatusCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, custErr.Message)
+}
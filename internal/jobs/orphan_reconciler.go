@@ -0,0 +1,313 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/grpc/client"
+	"library-api-category/internal/repositories"
+	"library-api-category/internal/services"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// orphanLockKey is the advisory lock that keeps replicas from reconciling at
+// the same time.
+const orphanLockKey = 7_240_040
+
+// maxReportedOrphans caps the orphan IDs kept in OrphanProgress.
+const maxReportedOrphans = 1000
+
+// ErrReconcileRunning is returned by Trigger while a run is in progress.
+var ErrReconcileRunning = errors.New("orphan reconciliation is already running")
+
+// OrphanProgress describes the current or last run of an OrphanReconciler.
+type OrphanProgress struct {
+	Running       bool
+	DryRun        bool
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	LastBookID    uint64
+	CheckedBooks  int
+	OrphanBooks   int
+	OrphanBookIDs []uint64
+	Removed       int
+	Error         string
+}
+
+// OrphanReconciler finds books that have categories here but no longer exist
+// in the book service, and reports or unassigns them. Pages are walked in
+// book ID order; a lookup failure other than not found ends the run so an
+// outage never removes assignments.
+type OrphanReconciler struct {
+	DB                 *sql.DB
+	CategoryRepository repositories.CategoryRepository
+	CategoryService    services.CategoryService
+	BookLookup         client.BookLookup
+	Interval           time.Duration
+	PageSize           int
+	Concurrency        int
+	// DryRun applies to scheduled runs, on-demand runs choose for themselves.
+	DryRun bool
+
+	mu       sync.Mutex
+	progress OrphanProgress
+}
+
+func NewOrphanReconciler(db *sql.DB, CategoryRepository repositories.CategoryRepository, CategoryService services.CategoryService, bookLookup client.BookLookup, interval time.Duration, pageSize int, dryRun bool) *OrphanReconciler {
+	return &OrphanReconciler{
+		DB:                 db,
+		CategoryRepository: CategoryRepository,
+		CategoryService:    CategoryService,
+		BookLookup:         bookLookup,
+		Interval:           interval,
+		PageSize:           pageSize,
+		Concurrency:        8,
+		DryRun:             dryRun,
+	}
+}
+
+// Run reconciles every Interval until ctx is cancelled. A zero Interval leaves
+// only on-demand runs.
+func (reconciler *OrphanReconciler) Run(ctx context.Context) {
+	if reconciler.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(reconciler.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := reconciler.Reconcile(ctx, reconciler.DryRun)
+		if err != nil && !errors.Is(err, ErrReconcileRunning) {
+			log.Printf("orphan reconciler: %v", err)
+		}
+	}
+}
+
+// Trigger starts a run in the background and returns once it holds the
+// advisory lock, so a run on another replica is reported as
+// ErrReconcileRunning. The caller's identity is kept for the audit log and
+// the policy checks but not its cancellation.
+func (reconciler *OrphanReconciler) Trigger(ctx context.Context, dryRun bool) error {
+	previous, ok := reconciler.start(dryRun)
+	if !ok {
+		return ErrReconcileRunning
+	}
+
+	unlock, err := reconciler.lock(ctx)
+	if err != nil {
+		reconciler.abort(previous, err)
+		return err
+	}
+
+	runCtx := context.Background()
+	for _, key := range []string{"authId", "role", "apiKeyId", "scopes", "requestId"} {
+		if value := ctx.Value(key); value != nil {
+			runCtx = context.WithValue(runCtx, key, value)
+		}
+	}
+
+	go func() {
+		err := reconciler.reconcile(runCtx)
+		unlock()
+		if reconciler.finish(err) != nil {
+			log.Printf("orphan reconciler: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Reconcile runs to completion.
+func (reconciler *OrphanReconciler) Reconcile(ctx context.Context, dryRun bool) error {
+	previous, ok := reconciler.start(dryRun)
+	if !ok {
+		return ErrReconcileRunning
+	}
+
+	unlock, err := reconciler.lock(ctx)
+	if err != nil {
+		reconciler.abort(previous, err)
+		return err
+	}
+	err = reconciler.reconcile(ctx)
+	unlock()
+	return reconciler.finish(err)
+}
+
+// Progress returns a snapshot of the current or last run.
+func (reconciler *OrphanReconciler) Progress() OrphanProgress {
+	reconciler.mu.Lock()
+	defer reconciler.mu.Unlock()
+
+	progress := reconciler.progress
+	progress.OrphanBookIDs = append([]uint64(nil), progress.OrphanBookIDs...)
+	return progress
+}
+
+// start marks a run as started on this replica and returns the progress it
+// replaced.
+func (reconciler *OrphanReconciler) start(dryRun bool) (OrphanProgress, bool) {
+	reconciler.mu.Lock()
+	defer reconciler.mu.Unlock()
+
+	previous := reconciler.progress
+	if previous.Running {
+		return previous, false
+	}
+	reconciler.progress = OrphanProgress{
+		Running:   true,
+		DryRun:    dryRun,
+		StartedAt: time.Now(),
+	}
+	return previous, true
+}
+
+// abort ends a run that never got the lock. When another replica holds it,
+// the progress of the last run here is kept.
+func (reconciler *OrphanReconciler) abort(previous OrphanProgress, err error) {
+	if errors.Is(err, ErrReconcileRunning) {
+		reconciler.mu.Lock()
+		reconciler.progress = previous
+		reconciler.mu.Unlock()
+		return
+	}
+	reconciler.finish(err)
+}
+
+func (reconciler *OrphanReconciler) finish(err error) error {
+	reconciler.mu.Lock()
+	defer reconciler.mu.Unlock()
+
+	reconciler.progress.Running = false
+	reconciler.progress.FinishedAt = time.Now()
+	if err != nil {
+		reconciler.progress.Error = err.Error()
+	}
+	return err
+}
+
+// lock takes the advisory lock on a dedicated connection and returns the
+// function that releases it.
+func (reconciler *OrphanReconciler) lock(ctx context.Context) (func(), error) {
+	conn, err := reconciler.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, orphanLockKey).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrReconcileRunning
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, orphanLockKey)
+		conn.Close()
+	}, nil
+}
+
+func (reconciler *OrphanReconciler) reconcile(ctx context.Context) error {
+	dryRun := reconciler.Progress().DryRun
+	var afterID uint64
+	for {
+		bookIDs, err := reconciler.nextPage(ctx, afterID)
+		if err != nil {
+			return err
+		}
+		if len(bookIDs) == 0 {
+			return nil
+		}
+
+		orphans, err := reconciler.findOrphans(ctx, bookIDs)
+		if err != nil {
+			return err
+		}
+
+		removed := 0
+		if !dryRun {
+			for _, bookID := range orphans {
+				count, custErr := reconciler.CategoryService.RemoveAllBookCategories(ctx, bookID)
+				if custErr != nil {
+					return errors.New(custErr.Message)
+				}
+				removed += count
+			}
+		}
+
+		afterID = bookIDs[len(bookIDs)-1]
+		reconciler.record(afterID, len(bookIDs), orphans, removed)
+	}
+}
+
+func (reconciler *OrphanReconciler) nextPage(ctx context.Context, afterID uint64) ([]uint64, error) {
+	tx, err := reconciler.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return reconciler.CategoryRepository.ListAssignedBookIDs(ctx, tx, afterID, reconciler.PageSize)
+}
+
+// findOrphans looks bookIDs up with bounded concurrency and returns the ones
+// the book service does not know, in order.
+func (reconciler *OrphanReconciler) findOrphans(ctx context.Context, bookIDs []uint64) ([]uint64, error) {
+	missing := make([]bool, len(bookIDs))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(reconciler.Concurrency)
+	for i, bookID := range bookIDs {
+		group.Go(func() error {
+			_, err := reconciler.BookLookup.GetBook(groupCtx, bookID)
+			if errors.Is(err, client.ErrBookNotFound) {
+				missing[i] = true
+				return nil
+			}
+			return err
+		})
+	}
+
+	err := group.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []uint64
+	for i, bookID := range bookIDs {
+		if missing[i] {
+			orphans = append(orphans, bookID)
+		}
+	}
+	return orphans, nil
+}
+
+func (reconciler *OrphanReconciler) record(lastBookID uint64, checked int, orphans []uint64, removed int) {
+	reconciler.mu.Lock()
+	defer reconciler.mu.Unlock()
+
+	reconciler.progress.LastBookID = lastBookID
+	reconciler.progress.CheckedBooks += checked
+	reconciler.progress.OrphanBooks += len(orphans)
+	reconciler.progress.Removed += removed
+	for _, bookID := range orphans {
+		if len(reconciler.progress.OrphanBookIDs) >= maxReportedOrphans {
+			break
+		}
+		reconciler.progress.OrphanBookIDs = append(reconciler.progress.OrphanBookIDs, bookID)
+	}
+}
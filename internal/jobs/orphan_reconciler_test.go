@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/grpc/client"
+	"library-api-category/internal/repositories"
+	"library-api-category/internal/services"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type stubCategoryRepository struct {
+	repositories.CategoryRepository
+	bookIDs []uint64
+}
+
+func (repository *stubCategoryRepository) ListAssignedBookIDs(ctx context.Context, tx *sql.Tx, afterID uint64, limit int) ([]uint64, error) {
+	var page []uint64
+	for _, bookID := range repository.bookIDs {
+		if bookID > afterID && len(page) < limit {
+			page = append(page, bookID)
+		}
+	}
+	return page, nil
+}
+
+type stubCategoryService struct {
+	services.CategoryService
+	removed chan context.Context
+}
+
+func (service *stubCategoryService) RemoveAllBookCategories(ctx context.Context, bookID uint64) (int, *response.CustomError) {
+	service.removed <- ctx
+	return 1, nil
+}
+
+func newTestReconciler(t *testing.T, bookIDs ...uint64) (*OrphanReconciler, *stubCategoryService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	service := &stubCategoryService{removed: make(chan context.Context, len(bookIDs))}
+	reconciler := NewOrphanReconciler(db, &stubCategoryRepository{bookIDs: bookIDs}, service, client.NewFakeBookLookup(), 0, 10, true)
+	return reconciler, service, mock
+}
+
+func expectLock(mock sqlmock.Sqlmock, locked bool) {
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+		WithArgs(orphanLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(locked))
+}
+
+func TestTriggerLockedByAnotherReplica(t *testing.T) {
+	reconciler, _, mock := newTestReconciler(t, 1)
+	last := OrphanProgress{DryRun: true, CheckedBooks: 5, FinishedAt: time.Now()}
+	reconciler.progress = last
+	expectLock(mock, false)
+
+	err := reconciler.Trigger(context.Background(), false)
+	if !errors.Is(err, ErrReconcileRunning) {
+		t.Fatalf("err = %v, want ErrReconcileRunning", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	progress := reconciler.Progress()
+	if progress.Running || progress.CheckedBooks != last.CheckedBooks || progress.Error != "" {
+		t.Errorf("progress = %+v, want the last run kept", progress)
+	}
+}
+
+func TestTriggerLockFailure(t *testing.T) {
+	reconciler, _, mock := newTestReconciler(t, 1)
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).WillReturnError(errors.New("connection refused"))
+
+	err := reconciler.Trigger(context.Background(), false)
+	if err == nil || errors.Is(err, ErrReconcileRunning) {
+		t.Fatalf("err = %v, want the lock error", err)
+	}
+
+	progress := reconciler.Progress()
+	if progress.Running || progress.Error == "" {
+		t.Errorf("progress = %+v, want a finished run with the error", progress)
+	}
+}
+
+func TestTriggerKeepsCallerIdentity(t *testing.T) {
+	reconciler, service, mock := newTestReconciler(t, 1)
+	expectLock(mock, true)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).
+		WithArgs(orphanLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	caller, cancel := context.WithCancel(context.Background())
+	for key, value := range map[string]any{
+		"authId":    1,
+		"role":      "admin",
+		"apiKeyId":  uint64(7),
+		"scopes":    []string{"assignment:write:any"},
+		"requestId": "req-1",
+	} {
+		caller = context.WithValue(caller, key, value)
+	}
+
+	err := reconciler.Trigger(caller, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	var runCtx context.Context
+	select {
+	case runCtx = <-service.removed:
+	case <-time.After(time.Second):
+		t.Fatal("orphan not removed")
+	}
+
+	if runCtx.Err() != nil {
+		t.Errorf("run cancelled with the caller: %v", runCtx.Err())
+	}
+	for _, key := range []string{"authId", "role", "apiKeyId", "scopes", "requestId"} {
+		if runCtx.Value(key) == nil {
+			t.Errorf("%s not kept", key)
+		}
+	}
+
+	for deadline := time.Now().Add(time.Second); reconciler.Progress().Running; {
+		if time.Now().After(deadline) {
+			t.Fatal("run did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,40 +1,39 @@
 package middleware
 
 import (
-	"context"
+	"errors"
 	"library-api-category/internal/commons/response"
 	"library-api-category/internal/grpc/client"
+	"library-api-category/internal/policy"
+	"library-api-category/internal/services"
+	tkn "library-api-category/pkg/token"
+	"log"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CheckAuth(authClient *client.AuthClient) gin.HandlerFunc {
+// Authenticate validates the bearer token and stores the caller's authId and
+// role. A request carrying X-API-Key is authenticated by the key instead and
+// gets the key's permissions as scopes. Missing or invalid credentials get 401,
+// and 503 is returned when the token cannot be checked.
+func Authenticate(authClient client.TokenValidator, apiKeys services.APIKeyService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		header := ctx.GetHeader("Authorization")
-		bearerToken := strings.Split(header, "Bearer ")
+		if secret := ctx.GetHeader("X-API-Key"); secret != "" && apiKeys != nil {
+			apiKey, custErr := apiKeys.AuthenticateAPIKey(ctx, secret)
+			if custErr != nil {
+				ctx.AbortWithStatusJSON(custErr.StatusCode, custErr)
+				return
+			}
 
-		if len(bearerToken) != 2 {
-			resp := response.UnauthorizedErrorWithAdditionalInfo("len token must be 2")
-			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			ctx.Set("apiKeyId", apiKey.ID)
+			ctx.Set("role", policy.APIKeyRole)
+			ctx.Set("scopes", apiKey.Permissions)
+			ctx.Next()
 			return
 		}
 
-		valid, payload := authClient.ValidateToken(context.Background(), bearerToken[1])
-		if !valid {
-			resp := response.UnauthorizedErrorWithAdditionalInfo("Invalid token")
-			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
-			return
-		}
-
-		ctx.Set("authId", payload.AuthId)
-		ctx.Set("role", payload.Role)
-		ctx.Next()
-	}
-}
-
-func CheckAuthIsAdmin(authClient *client.AuthClient) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
 		header := ctx.GetHeader("Authorization")
 		bearerToken := strings.Split(header, "Bearer ")
 
@@ -44,15 +43,15 @@ func CheckAuthIsAdmin(authClient *client.AuthClient) gin.HandlerFunc {
 			return
 		}
 
-		valid, payload := authClient.ValidateToken(context.Background(), bearerToken[1])
-		if !valid {
+		payload, err := authClient.ValidateToken(ctx.Request.Context(), bearerToken[1])
+		if errors.Is(err, tkn.ErrInvalidToken) {
 			resp := response.UnauthorizedErrorWithAdditionalInfo("Invalid token")
 			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
 			return
 		}
-
-		if payload.Role != "admin" {
-			resp := response.UnauthorizedErrorWithAdditionalInfo("user doesn't have permission to access")
+		if err != nil {
+			log.Printf("token validation failed: %v", err)
+			resp := response.ServiceUnavailableError("Authentication is unavailable, try again later")
 			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
 			return
 		}
@@ -63,31 +62,44 @@ func CheckAuthIsAdmin(authClient *client.AuthClient) gin.HandlerFunc {
 	}
 }
 
-func CheckAuthIsAdminOrAuthor(authClient *client.AuthClient) gin.HandlerFunc {
+// OptionalAuthenticate lets requests without credentials through as
+// anonymous and checks the ones that carry a token or API key like
+// Authenticate does, so public routes still tell callers apart.
+func OptionalAuthenticate(authClient client.TokenValidator, apiKeys services.APIKeyService) gin.HandlerFunc {
+	authenticate := Authenticate(authClient, apiKeys)
 	return func(ctx *gin.Context) {
-		header := ctx.GetHeader("Authorization")
-		bearerToken := strings.Split(header, "Bearer ")
-
-		if len(bearerToken) != 2 {
-			resp := response.UnauthorizedErrorWithAdditionalInfo("len token must be 2")
-			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+		if ctx.GetHeader("X-API-Key") == "" && ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
 			return
 		}
+		authenticate(ctx)
+	}
+}
 
-		valid, payload := authClient.ValidateToken(context.Background(), bearerToken[1])
-		if !valid {
-			resp := response.UnauthorizedErrorWithAdditionalInfo("Invalid token")
+// Authorize lets the request through when the caller's role holds permission
+// in the policy, or for API keys when the key was granted it. It must run
+// after Authenticate; unauthenticated callers get 401 and authenticated
+// callers without the permission get 403.
+func Authorize(engine *policy.Engine, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role, ok := ctx.Get("role")
+		if !ok {
+			resp := response.UnauthorizedErrorWithAdditionalInfo("authentication is required")
 			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
 			return
 		}
 
-		if payload.Role == "user" {
-			resp := response.UnauthorizedErrorWithAdditionalInfo("user doesn't have permission to access")
+		allowed := engine.Allowed(role.(string), permission)
+		if scopes, ok := ctx.Get("scopes"); ok {
+			allowed = slices.Contains(scopes.([]string), permission)
+		}
+
+		if !allowed {
+			resp := response.ForbiddenErrorWithAdditionalInfo(permission, "user doesn't have permission to access")
 			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
 			return
 		}
-		ctx.Set("authId", payload.AuthId)
-		ctx.Set("role", payload.Role)
+
 		ctx.Next()
 	}
 }
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig lists what cross-origin browsers are allowed to do. An origin
+// of "*" allows every origin; "https://*.example.com" allows the subdomains
+// of example.com, but not example.com itself.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS answers preflight requests and adds CORS headers to the responses of
+// allowed origins, echoing the origin back. The wildcard is only sent as is
+// when credentials are not allowed, since browsers reject it otherwise.
+func CORS(config CORSConfig) gin.HandlerFunc {
+	origins := trimAll(config.AllowedOrigins)
+	allowedMethods := strings.Join(trimAll(config.AllowedMethods), ", ")
+	allowedHeaders := strings.Join(trimAll(config.AllowedHeaders), ", ")
+	exposedHeaders := strings.Join(trimAll(config.ExposedHeaders), ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		origin := ctx.GetHeader("Origin")
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+
+		allowed, wildcard := matchOrigin(origins, origin)
+		if !allowed {
+			if preflight {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		if wildcard && !config.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+			ctx.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowedMethods)
+		header.Set("Access-Control-Allow-Headers", allowedHeaders)
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		ctx.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// matchOrigin reports whether origin is allowed, and whether only because
+// every origin is.
+func matchOrigin(patterns []string, origin string) (bool, bool) {
+	origin = strings.ToLower(origin)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" {
+			return true, true
+		}
+
+		prefix, suffix, hasWildcard := strings.Cut(pattern, "*")
+		if !hasWildcard {
+			if pattern == origin {
+				return true, false
+			}
+			continue
+		}
+
+		if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+		if !strings.ContainsAny(subdomain, "/:@") {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+func trimAll(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
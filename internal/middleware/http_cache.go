@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPCache adds Cache-Control and a weak ETag to successful responses and
+// answers conditional requests with 304 Not Modified. Handlers may set a
+// Last-Modified header, which is then honored for If-Modified-Since. An empty
+// cacheControl disables the middleware for the route.
+//
+// shared marks routes served to anonymous callers with the same body for
+// everyone, which CDNs and proxies may cache as public. Other responses are
+// private and vary on the credentials, so a shared cache never hands one
+// caller's body to another.
+func HTTPCache(cacheControl string, shared bool) gin.HandlerFunc {
+	if cacheControl != "" && !strings.Contains(cacheControl, "public") && !strings.Contains(cacheControl, "private") {
+		if shared {
+			cacheControl = "public, " + cacheControl
+		} else {
+			cacheControl = "private, " + cacheControl
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		if cacheControl == "" || (ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead) {
+			ctx.Next()
+			return
+		}
+
+		original := ctx.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		ctx.Writer = writer
+		ctx.Next()
+		ctx.Writer = original
+
+		if writer.status != http.StatusOK {
+			original.WriteHeader(writer.status)
+			original.Write(writer.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(writer.body.Bytes())
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+		header := original.Header()
+		header.Set("Cache-Control", cacheControl)
+		header.Set("ETag", etag)
+		if !shared {
+			header.Add("Vary", "Authorization, X-API-Key")
+		}
+
+		if notModified(ctx.Request, etag, header.Get("Last-Modified")) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+
+		original.WriteHeader(writer.status)
+		original.Write(writer.body.Bytes())
+	}
+}
+
+// notModified applies RFC 9110 precedence: If-None-Match wins over If-Modified-Since.
+func notModified(req *http.Request, etag string, lastModified string) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := req.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// bufferedWriter holds the response back so headers can still be changed once
+// the handler has rendered the body.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (writer *bufferedWriter) WriteHeader(code int) {
+	writer.status = code
+}
+
+func (writer *bufferedWriter) WriteHeaderNow() {
+}
+
+func (writer *bufferedWriter) Write(data []byte) (int, error) {
+	return writer.body.Write(data)
+}
+
+func (writer *bufferedWriter) WriteString(s string) (int, error) {
+	return writer.body.WriteString(s)
+}
+
+func (writer *bufferedWriter) Status() int {
+	return writer.status
+}
+
+func (writer *bufferedWriter) Size() int {
+	return writer.body.Len()
+}
+
+func (writer *bufferedWriter) Written() bool {
+	return writer.body.Len() > 0
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var lastModified = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newCacheRouter(cacheControl string, shared bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/categories", HTTPCache(cacheControl, shared), func(ctx *gin.Context) {
+		ctx.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+		ctx.JSON(http.StatusOK, gin.H{"name": "Fiction"})
+	})
+	router.GET("/missing", HTTPCache(cacheControl, shared), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "not found"})
+	})
+	return router
+}
+
+func get(router *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestHTTPCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		shared       bool
+		wantControl  string
+		wantVary     string
+	}{
+		{
+			name:         "private route varies on credentials",
+			cacheControl: "max-age=30",
+			wantControl:  "private, max-age=30",
+			wantVary:     "Authorization, X-API-Key",
+		},
+		{
+			name:         "shared route is public",
+			cacheControl: "max-age=30",
+			shared:       true,
+			wantControl:  "public, max-age=30",
+		},
+		{
+			name:         "explicit visibility is kept",
+			cacheControl: "public, s-maxage=300",
+			shared:       true,
+			wantControl:  "public, s-maxage=300",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := get(newCacheRouter(tt.cacheControl, tt.shared), "/categories", nil)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", recorder.Code)
+			}
+			if got := recorder.Header().Get("Cache-Control"); got != tt.wantControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantControl)
+			}
+			if got := recorder.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("Vary = %q, want %q", got, tt.wantVary)
+			}
+			if recorder.Header().Get("ETag") == "" {
+				t.Errorf("no ETag")
+			}
+		})
+	}
+}
+
+func TestHTTPCacheConditionalRequests(t *testing.T) {
+	router := newCacheRouter("max-age=30", false)
+	etag := get(router, "/categories", nil).Header().Get("ETag")
+	strong := etag[len("W/"):]
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{
+			name: "unconditional",
+			want: http.StatusOK,
+		},
+		{
+			name:    "matching ETag",
+			headers: map[string]string{"If-None-Match": etag},
+			want:    http.StatusNotModified,
+		},
+		{
+			name:    "matching ETag compared weakly",
+			headers: map[string]string{"If-None-Match": strong},
+			want:    http.StatusNotModified,
+		},
+		{
+			name:    "ETag in a list",
+			headers: map[string]string{"If-None-Match": `W/"other", ` + etag},
+			want:    http.StatusNotModified,
+		},
+		{
+			name:    "wildcard",
+			headers: map[string]string{"If-None-Match": "*"},
+			want:    http.StatusNotModified,
+		},
+		{
+			name:    "stale ETag",
+			headers: map[string]string{"If-None-Match": `W/"other"`},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "not modified since",
+			headers: map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			want:    http.StatusNotModified,
+		},
+		{
+			name:    "modified since",
+			headers: map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "unparsable date",
+			headers: map[string]string{"If-Modified-Since": "yesterday"},
+			want:    http.StatusOK,
+		},
+		{
+			name: "stale ETag wins over If-Modified-Since",
+			headers: map[string]string{
+				"If-None-Match":     `W/"other"`,
+				"If-Modified-Since": lastModified.Add(time.Hour).Format(http.TimeFormat),
+			},
+			want: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := get(router, "/categories", tt.headers)
+
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.want)
+			}
+			if got := recorder.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			if tt.want == http.StatusNotModified && recorder.Body.Len() != 0 {
+				t.Errorf("304 with a body: %s", recorder.Body.String())
+			}
+			if tt.want == http.StatusOK && recorder.Body.Len() == 0 {
+				t.Errorf("200 without a body")
+			}
+		})
+	}
+}
+
+func TestHTTPCacheSkipsErrors(t *testing.T) {
+	recorder := get(newCacheRouter("max-age=30", false), "/missing", map[string]string{"If-None-Match": "*"})
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", recorder.Code)
+	}
+	if recorder.Header().Get("ETag") != "" || recorder.Header().Get("Cache-Control") != "" {
+		t.Errorf("error response got cache headers: %v", recorder.Header())
+	}
+}
+
+func TestHTTPCacheDisabled(t *testing.T) {
+	recorder := get(newCacheRouter("", false), "/categories", map[string]string{"If-None-Match": "*"})
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	if recorder.Header().Get("ETag") != "" || recorder.Header().Get("Cache-Control") != "" {
+		t.Errorf("disabled route got cache headers: %v", recorder.Header())
+	}
+}
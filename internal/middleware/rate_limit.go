@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/ratelimit"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rateLimitCheck struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+// RateLimit counts the request against the caller's buckets and answers 429
+// once one is empty. Callers are told apart by authId or API key, and by
+// client IP for anonymous callers, so it must run after Authenticate or
+// OptionalAuthenticate.
+// A nil limiter disables it. Limiter errors let the request through.
+func RateLimit(limiter ratelimit.Limiter, rules *ratelimit.Rules) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if limiter == nil {
+			ctx.Next()
+			return
+		}
+
+		caller, role := rateLimitCaller(ctx)
+		checks := []rateLimitCheck{{key: caller, limit: rules.RoleLimit(role)}}
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		if limit, ok := rules.Routes[route]; ok {
+			checks = append(checks, rateLimitCheck{key: caller + "|" + route, limit: limit})
+		}
+
+		var reported *ratelimit.Result
+		for _, check := range checks {
+			result, err := limiter.Allow(ctx, check.key, check.limit)
+			if err != nil {
+				log.Printf("rate limit of %s not checked: %v", check.key, err)
+				continue
+			}
+
+			if reported == nil || !result.Allowed || result.Remaining < reported.Remaining {
+				reported = &result
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+		if reported == nil {
+			ctx.Next()
+			return
+		}
+
+		header := ctx.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(reported.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(reported.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reported.Reset)))
+
+		if !reported.Allowed {
+			retryAfter := max(ceilSeconds(reported.RetryAfter), 1)
+			header.Set("Retry-After", strconv.Itoa(retryAfter))
+			resp := response.TooManyRequestsError(fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter))
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func rateLimitCaller(ctx *gin.Context) (string, string) {
+	role := ctx.GetString("role")
+	if apiKeyId, ok := ctx.Get("apiKeyId"); ok {
+		return fmt.Sprintf("api_key:%d", apiKeyId), role
+	}
+	if authId, ok := ctx.Get("authId"); ok {
+		return fmt.Sprintf("user:%d", authId), role
+	}
+	return "ip:" + ctx.ClientIP(), ratelimit.AnonymousRole
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"library-api-category/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+// newRateLimitRouter identifies callers from the X-Test-Role and X-Test-Auth
+// headers in place of Authenticate.
+func newRateLimitRouter(limiter ratelimit.Limiter, rules *ratelimit.Rules) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	identify := func(ctx *gin.Context) {
+		if id := ctx.GetHeader("X-Test-Auth"); id != "" {
+			authId, _ := strconv.Atoi(id)
+			ctx.Set("authId", authId)
+			ctx.Set("role", ctx.GetHeader("X-Test-Role"))
+		}
+	}
+	handler := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+
+	router.GET("/categories", identify, RateLimit(limiter, rules), handler)
+	router.POST("/categories", identify, RateLimit(limiter, rules), handler)
+	return router
+}
+
+func serveAs(router *gin.Engine, method string, role string, authId string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/categories", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if authId != "" {
+		req.Header.Set("X-Test-Auth", authId)
+		req.Header.Set("X-Test-Role", role)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimitPerRole(t *testing.T) {
+	rules := &ratelimit.Rules{
+		Default: ratelimit.Limit{Rate: 0.001, Burst: 2},
+		Roles: map[string]ratelimit.Limit{
+			"admin":                 {Rate: 0.001, Burst: 4},
+			ratelimit.AnonymousRole: {Rate: 0.001, Burst: 1},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		role    string
+		authId  string
+		allowed int
+	}{
+		{name: "anonymous", allowed: 1},
+		{name: "role without a limit", role: "user", authId: "1", allowed: 2},
+		{name: "role with a limit", role: "admin", authId: "2", allowed: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRateLimitRouter(ratelimit.NewMemoryLimiter(), rules)
+
+			for i := 0; i < tt.allowed; i++ {
+				recorder := serveAs(router, http.MethodGet, tt.role, tt.authId)
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("request %d status = %d, want 200", i+1, recorder.Code)
+				}
+				if got := recorder.Header().Get("RateLimit-Remaining"); got != strconv.Itoa(tt.allowed-i-1) {
+					t.Errorf("request %d RateLimit-Remaining = %s, want %d", i+1, got, tt.allowed-i-1)
+				}
+			}
+
+			recorder := serveAs(router, http.MethodGet, tt.role, tt.authId)
+			if recorder.Code != http.StatusTooManyRequests {
+				t.Fatalf("status = %d, want 429", recorder.Code)
+			}
+			if recorder.Header().Get("Retry-After") == "" {
+				t.Errorf("429 without Retry-After")
+			}
+			if got := recorder.Header().Get("RateLimit-Limit"); got != strconv.Itoa(tt.allowed) {
+				t.Errorf("RateLimit-Limit = %s, want %d", got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestRateLimitSeparatesCallers(t *testing.T) {
+	rules := &ratelimit.Rules{Default: ratelimit.Limit{Rate: 0.001, Burst: 1}}
+	router := newRateLimitRouter(ratelimit.NewMemoryLimiter(), rules)
+
+	for _, authId := range []string{"1", "2"} {
+		if code := serveAs(router, http.MethodGet, "user", authId).Code; code != http.StatusOK {
+			t.Errorf("user %s status = %d, want 200", authId, code)
+		}
+	}
+	if code := serveAs(router, http.MethodGet, "user", "1").Code; code != http.StatusTooManyRequests {
+		t.Errorf("user 1 again status = %d, want 429", code)
+	}
+}
+
+func TestRateLimitRoutes(t *testing.T) {
+	rules := &ratelimit.Rules{
+		Default: ratelimit.Limit{Rate: 0.001, Burst: 10},
+		Routes: map[string]ratelimit.Limit{
+			"POST /categories": {Rate: 0.001, Burst: 1},
+		},
+	}
+	router := newRateLimitRouter(ratelimit.NewMemoryLimiter(), rules)
+
+	recorder := serveAs(router, http.MethodPost, "user", "1")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("first POST status = %d, want 200", recorder.Code)
+	}
+	if got := recorder.Header().Get("RateLimit-Limit"); got != "1" {
+		t.Errorf("RateLimit-Limit = %s, want the tighter route limit 1", got)
+	}
+	if code := serveAs(router, http.MethodPost, "user", "1").Code; code != http.StatusTooManyRequests {
+		t.Errorf("second POST status = %d, want 429", code)
+	}
+	if code := serveAs(router, http.MethodGet, "user", "1").Code; code != http.StatusOK {
+		t.Errorf("GET status = %d, want 200: the route limit only applies to POST", code)
+	}
+}
+
+func TestRateLimitLetsRequestsThrough(t *testing.T) {
+	rules := &ratelimit.Rules{Default: ratelimit.Limit{Rate: 0.001, Burst: 1}}
+
+	for name, limiter := range map[string]ratelimit.Limiter{
+		"disabled":       nil,
+		"limiter errors": failingLimiter{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := newRateLimitRouter(limiter, rules)
+			for i := 0; i < 3; i++ {
+				recorder := serveAs(router, http.MethodGet, "user", "1")
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("request %d status = %d, want 200", i+1, recorder.Code)
+				}
+				if recorder.Header().Get("RateLimit-Limit") != "" {
+					t.Errorf("rate limit headers sent without a result")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID reuses the caller's X-Request-ID or generates a new one, so audit
+// entries can be correlated with access logs.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestId := ctx.GetHeader(RequestIDHeader)
+		if requestId == "" || len(requestId) > 64 {
+			buf := make([]byte, 16)
+			rand.Read(buf)
+			requestId = hex.EncodeToString(buf)
+		}
+
+		ctx.Set("requestId", requestId)
+		ctx.Writer.Header().Set(RequestIDHeader, requestId)
+		ctx.Next()
+	}
+}
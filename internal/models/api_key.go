@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type APIKey struct {
+	ID          uint64
+	Name        string
+	Prefix      string
+	KeyHash     string
+	Permissions []string
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	CreatedBy   uint64
+	CreatedAt   time.Time
+	RevokedAt   *time.Time
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityCategory     = "category"
+	AuditEntityBookCategory = "book_category"
+
+	AuditActionCategoryCreate     = "category.create"
+	AuditActionCategoryUpdate     = "category.update"
+	AuditActionCategoryDelete     = "category.delete"
+	AuditActionCategoryRevert     = "category.revert"
+	AuditActionBookCategoryAdd    = "book_category.add"
+	AuditActionBookCategoryRemove = "book_category.remove"
+)
+
+type AuditLog struct {
+	ID         uint64
+	ActorID    uint64
+	ActorRole  string
+	Action     string
+	EntityType string
+	EntityID   uint64
+	Before     json.RawMessage
+	After      json.RawMessage
+	RequestID  string
+	CreatedAt  time.Time
+}
+
+// AuditFilter narrows an audit log query, zero values are ignored.
+type AuditFilter struct {
+	ActorID    uint64
+	Action     string
+	EntityType string
+	EntityID   uint64
+	From       time.Time
+	To         time.Time
+}
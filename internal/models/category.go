@@ -14,3 +14,13 @@ type BookCategory struct {
 	BookID     uint64
 	CategoryID uint64
 }
+
+type CategoryRevision struct {
+	ID          uint64
+	CategoryID  uint64
+	Revision    uint64
+	Name        string
+	Description string
+	ActorID     uint64
+	CreatedAt   time.Time
+}
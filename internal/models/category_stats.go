@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type CategoryStats struct {
+	TotalCategories  uint64
+	TotalAssignments uint64
+	CategorizedBooks uint64
+	EmptyCategories  uint64
+}
+
+type CategoryUsage struct {
+	CategoryID uint64
+	Name       string
+	BookCount  uint64
+}
+
+type DailyAssignments struct {
+	Day   time.Time
+	Count uint64
+}
+
+// CategoryCountDrift is a category whose materialized book count differs from
+// its assignments.
+type CategoryCountDrift struct {
+	CategoryID  uint64
+	StoredCount uint64
+	ActualCount uint64
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventCategoryCreated      = "CategoryCreated"
+	EventCategoryUpdated      = "CategoryUpdated"
+	EventCategoryDeleted      = "CategoryDeleted"
+	EventBookCategoryAssigned = "BookCategoryAssigned"
+	EventBookCategoryRemoved  = "BookCategoryRemoved"
+
+	AggregateCategory = "category"
+	AggregateBook     = "book"
+)
+
+type OutboxEvent struct {
+	ID            uint64
+	EventType     string
+	AggregateType string
+	AggregateID   uint64
+	Payload       json.RawMessage
+	Attempts      int
+	CreatedAt     time.Time
+}
@@ -8,4 +8,32 @@ type CategoryResponse struct {
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	BookCount   *uint64   `json:"book_count,omitempty"`
+}
+
+type BatchCategoriesResponse struct {
+	Categories []*CategoryResponse `json:"categories"`
+	MissingIDs []uint64            `json:"missing_ids"`
+}
+
+type CategoryRevisionResponse struct {
+	Revision    uint64    `json:"revision"`
+	CategoryID  uint64    `json:"category_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ActorID     uint64    `json:"actor_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CategoryFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type CategoryRevisionDiffResponse struct {
+	CategoryID uint64                `json:"category_id"`
+	From       uint64                `json:"from"`
+	To         uint64                `json:"to"`
+	Changes    []CategoryFieldChange `json:"changes"`
 }
@@ -0,0 +1,37 @@
+package params
+
+import "time"
+
+type CategoryStatsResponse struct {
+	TotalCategories    uint64                      `json:"total_categories"`
+	TotalAssignments   uint64                      `json:"total_assignments"`
+	CategorizedBooks   uint64                      `json:"categorized_books"`
+	EmptyCategoryCount uint64                      `json:"empty_category_count"`
+	EmptyCategories    []*CategoryUsageResponse    `json:"empty_categories"`
+	MostUsed           []*CategoryUsageResponse    `json:"most_used"`
+	LeastUsed          []*CategoryUsageResponse    `json:"least_used"`
+	AssignmentsPerDay  []*DailyAssignmentsResponse `json:"assignments_per_day"`
+}
+
+type CategoryUsageResponse struct {
+	CategoryID uint64 `json:"category_id"`
+	Name       string `json:"name"`
+	BookCount  uint64 `json:"book_count"`
+}
+
+type DailyAssignmentsResponse struct {
+	Day   time.Time `json:"day"`
+	Count uint64    `json:"count"`
+}
+
+type CountDriftResponse struct {
+	CategoryID  uint64 `json:"category_id"`
+	StoredCount uint64 `json:"stored_count"`
+	ActualCount uint64 `json:"actual_count"`
+}
+
+type CountReconciliationResponse struct {
+	DryRun bool                  `json:"dry_run"`
+	Drift  []*CountDriftResponse `json:"drift"`
+	Fixed  int64                 `json:"fixed"`
+}
@@ -0,0 +1,16 @@
+package params
+
+import "time"
+
+type OrphanReconciliationResponse struct {
+	Running       bool       `json:"running"`
+	DryRun        bool       `json:"dry_run"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	LastBookID    uint64     `json:"last_book_id"`
+	CheckedBooks  int        `json:"checked_books"`
+	OrphanBooks   int        `json:"orphan_books"`
+	OrphanBookIDs []uint64   `json:"orphan_book_ids"`
+	Removed       int        `json:"removed_assignments"`
+	Error         string     `json:"error,omitempty"`
+}
@@ -0,0 +1,6 @@
+package params
+
+type TokenRevocationRequest struct {
+	Token  string `json:"token"`
+	AuthID int    `json:"auth_id"`
+}
@@ -0,0 +1,208 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	CategoryRead    = "category:read"
+	CategoryCreate  = "category:create"
+	CategoryUpdate  = "category:update"
+	CategoryDelete  = "category:delete"
+	CategoryRevert  = "category:revert"
+	AssignmentWrite = "assignment:write"
+	// AssignmentWriteAny lifts the restriction of AssignmentWrite to books
+	// authored by the caller.
+	AssignmentWriteAny = "assignment:write:any"
+	AuditRead          = "audit:read"
+	StatsRead          = "stats:read"
+	CacheRead          = "cache:read"
+	WebhookManage      = "webhook:manage"
+	ReconcileRun       = "reconcile:run"
+	TokenRevoke        = "token:revoke"
+	PolicyRead         = "policy:read"
+	PolicyReload       = "policy:reload"
+	APIKeyManage       = "api_key:manage"
+)
+
+// AnyRole grants a permission to every authenticated caller.
+const AnyRole = "*"
+
+// APIKeyRole is the role of callers authenticated with an API key. They are
+// authorized by the permissions of their key instead of the policy.
+const APIKeyRole = "api_key"
+
+// Policy maps each permission to the roles holding it. Permissions missing
+// from a policy are denied to everyone.
+type Policy struct {
+	Permissions map[string][]string `json:"permissions"`
+}
+
+// Default is the policy used when no file is configured, it matches the
+// role checks the routes had before policies existed.
+func Default() *Policy {
+	staff := []string{"admin", "author"}
+	admin := []string{"admin"}
+	return &Policy{Permissions: map[string][]string{
+		CategoryRead:       {AnyRole},
+		CategoryCreate:     staff,
+		CategoryUpdate:     staff,
+		CategoryDelete:     staff,
+		CategoryRevert:     admin,
+		AssignmentWrite:    staff,
+		AssignmentWriteAny: admin,
+		AuditRead:          admin,
+		StatsRead:          admin,
+		CacheRead:          admin,
+		WebhookManage:      admin,
+		ReconcileRun:       admin,
+		TokenRevoke:        admin,
+		PolicyRead:         admin,
+		PolicyReload:       admin,
+		APIKeyManage:       admin,
+	}}
+}
+
+// Known reports whether permission is one the routes check.
+func Known(permission string) bool {
+	_, ok := Default().Permissions[permission]
+	return ok
+}
+
+func (policy *Policy) allows(role string, permission string) bool {
+	for _, allowed := range policy.Permissions[permission] {
+		if allowed == AnyRole || allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Engine answers authorization questions from the current Policy. The policy
+// file is reloaded by Watch when it changes; a file that fails to load keeps
+// the previous policy in place.
+type Engine struct {
+	path    string
+	current atomic.Pointer[Policy]
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewEngine loads path, or uses Default when path is empty.
+func NewEngine(path string) (*Engine, error) {
+	engine := &Engine{path: path}
+	if path == "" {
+		engine.current.Store(Default())
+		return engine, nil
+	}
+
+	err := engine.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+func (engine *Engine) Allowed(role string, permission string) bool {
+	return role != "" && engine.current.Load().allows(role, permission)
+}
+
+// Policy returns the policy in effect.
+func (engine *Engine) Policy() *Policy {
+	return engine.current.Load()
+}
+
+// Reload reads the policy file again.
+func (engine *Engine) Reload() error {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	if engine.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(engine.path)
+	if err != nil {
+		return err
+	}
+	policy, err := load(engine.path)
+	if err != nil {
+		return err
+	}
+
+	engine.current.Store(policy)
+	engine.modTime = info.ModTime()
+	return nil
+}
+
+// Watch reloads the policy file whenever its modification time changes,
+// checking every interval until ctx is cancelled.
+func (engine *Engine) Watch(ctx context.Context, interval time.Duration) {
+	if engine.path == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(engine.path)
+		if err != nil {
+			log.Printf("policy: %v", err)
+			continue
+		}
+
+		engine.mu.Lock()
+		changed := !info.ModTime().Equal(engine.modTime)
+		engine.mu.Unlock()
+		if !changed {
+			continue
+		}
+
+		err = engine.Reload()
+		if err != nil {
+			log.Printf("policy: keeping the previous policy: %v", err)
+			continue
+		}
+		log.Printf("policy: reloaded %s", engine.path)
+	}
+}
+
+func load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	err = json.Unmarshal(data, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(policy.Permissions) == 0 {
+		return nil, fmt.Errorf("%s grants no permissions", path)
+	}
+
+	for permission, roles := range policy.Permissions {
+		for _, role := range roles {
+			if role == "" {
+				return nil, fmt.Errorf("%s: empty role in %q", path, permission)
+			}
+		}
+	}
+	return &policy, nil
+}
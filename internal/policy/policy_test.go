@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var permissions = []string{
+	CategoryRead, CategoryCreate, CategoryUpdate, CategoryDelete, CategoryRevert,
+	AssignmentWrite, AssignmentWriteAny, AuditRead, StatsRead, CacheRead,
+	WebhookManage, ReconcileRun, TokenRevoke, PolicyRead, PolicyReload, APIKeyManage,
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	engine, err := NewEngine("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readers := map[string]bool{"admin": true, "author": true, "user": true, "guest": true}
+	staff := map[string]bool{"admin": true, "author": true}
+	admins := map[string]bool{"admin": true}
+	want := map[string]map[string]bool{
+		CategoryRead:       readers,
+		CategoryCreate:     staff,
+		CategoryUpdate:     staff,
+		CategoryDelete:     staff,
+		CategoryRevert:     admins,
+		AssignmentWrite:    staff,
+		AssignmentWriteAny: admins,
+		AuditRead:          admins,
+		StatsRead:          admins,
+		CacheRead:          admins,
+		WebhookManage:      admins,
+		ReconcileRun:       admins,
+		TokenRevoke:        admins,
+		PolicyRead:         admins,
+		PolicyReload:       admins,
+		APIKeyManage:       admins,
+	}
+	if len(want) != len(permissions) {
+		t.Fatalf("table covers %d permissions, want %d", len(want), len(permissions))
+	}
+
+	for _, permission := range permissions {
+		for _, role := range []string{"admin", "author", "user", "guest", ""} {
+			t.Run(permission+" as "+role, func(t *testing.T) {
+				if got := engine.Allowed(role, permission); got != want[permission][role] {
+					t.Errorf("Allowed(%q, %q) = %v, want %v", role, permission, got, want[permission][role])
+				}
+			})
+		}
+	}
+
+	if engine.Allowed("admin", "category:archive") {
+		t.Error("unknown permission allowed")
+	}
+}
+
+func TestKnown(t *testing.T) {
+	for _, permission := range permissions {
+		if !Known(permission) {
+			t.Errorf("Known(%q) = false", permission)
+		}
+	}
+	if len(Default().Permissions) != len(permissions) {
+		t.Errorf("Default has %d permissions, the test lists %d", len(Default().Permissions), len(permissions))
+	}
+
+	for _, permission := range []string{"", "*", "category", "category:archive", "CATEGORY:READ"} {
+		if Known(permission) {
+			t.Errorf("Known(%q) = true", permission)
+		}
+	}
+}
+
+func TestShippedPolicyMatchesDefault(t *testing.T) {
+	engine, err := NewEngine(filepath.Join("..", "..", "policy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(engine.Policy(), Default()) {
+		t.Errorf("policy.json = %v, want Default %v", engine.Policy().Permissions, Default().Permissions)
+	}
+}
+
+func writePolicy(t *testing.T, path string, data string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(data), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngineReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, path, `{"permissions": {"category:read": ["user"]}}`)
+
+	engine, err := NewEngine(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !engine.Allowed("user", CategoryRead) || engine.Allowed("admin", CategoryRead) {
+		t.Fatalf("policy = %v", engine.Policy().Permissions)
+	}
+
+	writePolicy(t, path, `{"permissions": {"category:read": ["admin"]}}`)
+	if err := engine.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if engine.Allowed("user", CategoryRead) || !engine.Allowed("admin", CategoryRead) {
+		t.Errorf("reloaded policy = %v", engine.Policy().Permissions)
+	}
+
+	for name, data := range map[string]string{
+		"malformed":      `{"permissions":`,
+		"no permissions": `{"permissions": {}}`,
+		"empty role":     `{"permissions": {"category:read": [""]}}`,
+	} {
+		writePolicy(t, path, data)
+		if err := engine.Reload(); err == nil {
+			t.Errorf("%s: Reload succeeded", name)
+		}
+		if !engine.Allowed("admin", CategoryRead) {
+			t.Errorf("%s: previous policy not kept", name)
+		}
+	}
+}
+
+func TestNewEngineRejectsBadFile(t *testing.T) {
+	_, err := NewEngine(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Error("NewEngine succeeded without a policy file")
+	}
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result describes a bucket after a request was counted against it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when
+	// this one was.
+	RetryAfter time.Duration
+}
+
+// Limiter takes one token from the bucket of key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket holding tokens for elapsed and takes one token from
+// it when there is one. It returns the tokens left and the Result.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, result(tokens, allowed, limit)
+}
+
+// result describes a bucket left with tokens after a request.
+func result(tokens float64, allowed bool, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(tokens),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ParseLimit reads "rate:burst", with rate in requests per second. A missing
+// burst defaults to the rate rounded up.
+func ParseLimit(value string) (Limit, error) {
+	rateValue, burstValue, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid rate in %q", value)
+	}
+
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		burst, err = strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("ratelimit: invalid burst in %q", value)
+		}
+	}
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// ParseLimits reads comma separated "name=rate:burst" pairs, such as
+// "admin=50:100,user=5:10" or "POST /api/v1/categories=1:5".
+func ParseLimits(value string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		name, limitValue, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("ratelimit: expected name=rate:burst, got %q", pair)
+		}
+		limit, err := ParseLimit(limitValue)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits, nil
+}
+
+// AnonymousRole is the role used to look up the limit of unauthenticated
+// callers.
+const AnonymousRole = "anonymous"
+
+// Rules decides the limits of a request. Every caller has a bucket limited by
+// their role, or Default for roles without a limit. Routes listed in Routes,
+// keyed by "METHOD /path" as registered, get an extra bucket per caller.
+type Rules struct {
+	Default Limit
+	Roles   map[string]Limit
+	Routes  map[string]Limit
+}
+
+func (rules *Rules) RoleLimit(role string) Limit {
+	if limit, ok := rules.Roles[role]; ok {
+		return limit
+	}
+	return rules.Default
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+
+	tests := []struct {
+		name          string
+		tokens        float64
+		elapsed       time.Duration
+		wantTokens    float64
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+		wantRetry     time.Duration
+	}{
+		{
+			name:          "full bucket",
+			tokens:        3,
+			wantTokens:    2,
+			wantAllowed:   true,
+			wantRemaining: 2,
+			wantReset:     500 * time.Millisecond,
+		},
+		{
+			name:          "last token",
+			tokens:        1,
+			wantTokens:    0,
+			wantAllowed:   true,
+			wantRemaining: 0,
+			wantReset:     1500 * time.Millisecond,
+		},
+		{
+			name:        "empty bucket",
+			tokens:      0.5,
+			wantTokens:  0.5,
+			wantReset:   1250 * time.Millisecond,
+			wantRetry:   250 * time.Millisecond,
+			wantAllowed: false,
+		},
+		{
+			name:          "refilled",
+			tokens:        0,
+			elapsed:       500 * time.Millisecond,
+			wantTokens:    0,
+			wantAllowed:   true,
+			wantRemaining: 0,
+			wantReset:     1500 * time.Millisecond,
+		},
+		{
+			name:          "refill capped at burst",
+			tokens:        0,
+			elapsed:       time.Hour,
+			wantTokens:    2,
+			wantAllowed:   true,
+			wantRemaining: 2,
+			wantReset:     500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, result := take(tt.tokens, tt.elapsed, limit)
+
+			if tokens != tt.wantTokens {
+				t.Errorf("tokens = %v, want %v", tokens, tt.wantTokens)
+			}
+			want := Result{
+				Allowed:    tt.wantAllowed,
+				Limit:      limit.Burst,
+				Remaining:  tt.wantRemaining,
+				Reset:      tt.wantReset,
+				RetryAfter: tt.wantRetry,
+			}
+			if result != want {
+				t.Errorf("result = %+v, want %+v", result, want)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{value: "10:20", want: Limit{Rate: 10, Burst: 20}},
+		{value: " 0.5:2 ", want: Limit{Rate: 0.5, Burst: 2}},
+		{value: "2.5", want: Limit{Rate: 2.5, Burst: 3}},
+		{value: "0:5", wantErr: true},
+		{value: "-1:5", wantErr: true},
+		{value: "fast", wantErr: true},
+		{value: "1:0", wantErr: true},
+		{value: "1:many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLimit(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("admin=50:100, anonymous=1:2,,POST /api/v1/categories=1:5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Limit{
+		"admin":                   {Rate: 50, Burst: 100},
+		"anonymous":               {Rate: 1, Burst: 2},
+		"POST /api/v1/categories": {Rate: 1, Burst: 5},
+	}
+	if len(limits) != len(want) {
+		t.Fatalf("limits = %v, want %v", limits, want)
+	}
+	for name, limit := range want {
+		if limits[name] != limit {
+			t.Errorf("limits[%q] = %+v, want %+v", name, limits[name], limit)
+		}
+	}
+
+	for _, value := range []string{"admin", "admin=fast"} {
+		if _, err := ParseLimits(value); err == nil {
+			t.Errorf("ParseLimits(%q) succeeded", value)
+		}
+	}
+}
+
+func TestRulesRoleLimit(t *testing.T) {
+	rules := &Rules{
+		Default: Limit{Rate: 10, Burst: 20},
+		Roles:   map[string]Limit{"admin": {Rate: 50, Burst: 100}},
+	}
+
+	if got := rules.RoleLimit("admin"); got != (Limit{Rate: 50, Burst: 100}) {
+		t.Errorf("RoleLimit(admin) = %+v", got)
+	}
+	if got := rules.RoleLimit("user"); got != rules.Default {
+		t.Errorf("RoleLimit(user) = %+v, want the default", got)
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterAllow(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 0.001, Burst: 2}
+
+	for i, wantAllowed := range []bool{true, true, false, false} {
+		result, err := limiter.Allow(context.Background(), "user:1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != wantAllowed {
+			t.Errorf("request %d allowed = %v, want %v", i+1, result.Allowed, wantAllowed)
+		}
+	}
+
+	result, err := limiter.Allow(context.Background(), "user:2", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Remaining != 1 {
+		t.Errorf("other caller = %+v, want its own full bucket", result)
+	}
+}
+
+func TestMemoryLimiterRefills(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 1000, Burst: 1}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(context.Background(), "user:1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Fatalf("request %d refused after waiting %v", i+1, result.RetryAfter)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemoryLimiterSweepsFullBuckets(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 1000, Burst: 1}
+
+	_, err := limiter.Allow(context.Background(), "user:1", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	limiter.sweep(time.Now().Add(time.Minute))
+	if len(limiter.buckets) != 0 {
+		t.Errorf("%d buckets left after sweeping, want 0", len(limiter.buckets))
+	}
+}
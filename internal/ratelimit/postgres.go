@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// PostgresLimiter keeps buckets in the rate_limit_buckets table so every
+// replica draws from the same bucket. Each request is a single upsert.
+type PostgresLimiter struct {
+	db *sql.DB
+}
+
+func NewPostgresLimiter(db *sql.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (limiter *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	// available is the bucket refilled up to now, before this request.
+	available := `LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW()::timestamp - b.updated_at) * $3::float8)`
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, TRUE, NOW()::timestamp)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN ` + available + ` >= 1 THEN ` + available + ` - 1 ELSE ` + available + ` END,
+			allowed = ` + available + ` >= 1,
+			updated_at = NOW()::timestamp
+		RETURNING tokens, allowed`
+
+	var tokens float64
+	var allowed bool
+	err := limiter.db.QueryRowContext(ctx, query, key, limit.Burst, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, errors.New("Failed to update rate limit bucket. Reason: " + err.Error())
+	}
+
+	return result(tokens, allowed, limit), nil
+}
+
+// Run deletes buckets idle for an hour, every interval until ctx is
+// cancelled. Any bucket refilling faster than burst per hour is full by then.
+func (limiter *PostgresLimiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := limiter.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < NOW()::timestamp - INTERVAL '1 hour'`)
+		if err != nil {
+			log.Printf("ratelimit: pruning buckets: %v", err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrAPIKeyNotFound is returned when no api key matches, so callers can tell a
+// bad key from a failing database.
+var ErrAPIKeyNotFound = errors.New("api key is not found")
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, tx *sql.Tx, key *models.APIKey) error
+	FindAPIKeyByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, tx *sql.Tx, keyHash string) (*models.APIKey, error)
+	GetAllAPIKeys(ctx context.Context, tx *sql.Tx) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, tx *sql.Tx, id uint64, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, usedAt time.Time) error
+}
+
+type APIKeyRepositoryImpl struct {
+}
+
+func NewAPIKeyRepository() APIKeyRepository {
+	return &APIKeyRepositoryImpl{}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, permissions, expires_at, last_used_at, created_by, created_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Permissions), &key.ExpiresAt, &key.LastUsedAt, &key.CreatedBy, &key.CreatedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (repository *APIKeyRepositoryImpl) CreateAPIKey(ctx context.Context, tx *sql.Tx, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, permissions, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Permissions), key.ExpiresAt, key.CreatedBy, key.CreatedAt).Scan(&key.ID)
+	if err != nil {
+		return errors.New("Failed to create an api key, transaction rolled back. Reason: " + err.Error())
+	}
+
+	return nil
+}
+
+func (repository *APIKeyRepositoryImpl) FindAPIKeyByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(tx.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (repository *APIKeyRepositoryImpl) FindAPIKeyByHash(ctx context.Context, tx *sql.Tx, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := scanAPIKey(tx.QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (repository *APIKeyRepositoryImpl) GetAllAPIKeys(ctx context.Context, tx *sql.Tx) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (repository *APIKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, tx *sql.Tx, id uint64, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+
+	_, err := tx.ExecContext(ctx, query, revokedAt, id)
+	if err != nil {
+		return errors.New("Failed to revoke an api key, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+// TouchAPIKey records a use of the key. It writes at most once a minute per
+// key so busy callers don't turn every request into an update.
+func (repository *APIKeyRepositoryImpl) TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, usedAt time.Time) error {
+	query := `
+		UPDATE api_keys SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $1::timestamp - INTERVAL '1 minute')`
+
+	_, err := tx.ExecContext(ctx, query, usedAt, id)
+	if err != nil {
+		return errors.New("Failed to update api key usage. Reason: " + err.Error())
+	}
+	return nil
+}
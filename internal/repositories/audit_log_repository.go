@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"library-api-category/internal/models"
+	"strings"
+)
+
+type AuditLogRepository interface {
+	CreateAuditLog(ctx context.Context, tx *sql.Tx, audit *models.AuditLog) error
+	GetAllAuditLogs(ctx context.Context, tx *sql.Tx, filter *models.AuditFilter, pagination *models.Pagination) ([]*models.AuditLog, error)
+}
+
+type AuditLogRepositoryImpl struct {
+}
+
+func NewAuditLogRepository() AuditLogRepository {
+	return &AuditLogRepositoryImpl{}
+}
+
+func (repository *AuditLogRepositoryImpl) CreateAuditLog(ctx context.Context, tx *sql.Tx, audit *models.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (actor_id, actor_role, action, entity_type, entity_id, before, after, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query,
+		audit.ActorID,
+		audit.ActorRole,
+		audit.Action,
+		audit.EntityType,
+		audit.EntityID,
+		nullableJSON(audit.Before),
+		nullableJSON(audit.After),
+		audit.RequestID,
+		audit.CreatedAt,
+	).Scan(&audit.ID)
+	if err != nil {
+		return errors.New("Failed to create an audit log, transaction rolled back. Reason: " + err.Error())
+	}
+
+	return nil
+}
+
+func (repository *AuditLogRepositoryImpl) GetAllAuditLogs(ctx context.Context, tx *sql.Tx, filter *models.AuditFilter, pagination *models.Pagination) ([]*models.AuditLog, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(column string, operator string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", column, operator, len(args)))
+	}
+
+	if filter.ActorID != 0 {
+		addCondition("actor_id", "=", filter.ActorID)
+	}
+	if filter.Action != "" {
+		addCondition("action", "=", filter.Action)
+	}
+	if filter.EntityType != "" {
+		addCondition("entity_type", "=", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		addCondition("entity_id", "=", filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at", "<", filter.To)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_logs"+where, args...).Scan(&pagination.TotalCount)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_id, actor_role, action, entity_type, entity_id, before, after, COALESCE(request_id, ''), created_at
+		FROM audit_logs%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	rows, err := tx.QueryContext(ctx, query, append(args, pagination.PageSize, pagination.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audits []*models.AuditLog
+	for rows.Next() {
+		var audit models.AuditLog
+		var before, after []byte
+		err := rows.Scan(&audit.ID, &audit.ActorID, &audit.ActorRole, &audit.Action, &audit.EntityType, &audit.EntityID, &before, &after, &audit.RequestID, &audit.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		audit.Before = before
+		audit.After = after
+
+		audits = append(audits, &audit)
+	}
+	return audits, rows.Err()
+}
+
+// nullableJSON stores an empty snapshot as SQL NULL instead of an invalid JSONB value.
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
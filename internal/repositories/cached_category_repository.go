@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"library-api-category/internal/cache"
+	"library-api-category/internal/models"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	categoryCacheKey     = "category:"
+	bookCategoryCacheKey = "book_categories:"
+)
+
+// cacheLoadTimeout bounds a shared cache miss load, which no longer follows
+// the context of any one caller.
+const cacheLoadTimeout = 5 * time.Second
+
+// CachedCategoryRepository serves FindCategoryByID and ListCategoryOfBook from
+// a cache.GenerationalCache and invalidates it on every mutation going through
+// it. Keys are dropped right away and again, on every replica, through a NOTIFY
+// sent when the mutating transaction commits. A load that overlapped either
+// drop does not cache its result, since it may have read the data from before
+// the commit.
+//
+// Concurrent misses on one key share a single load, callers missing after an
+// invalidation start a new one. It runs in its own read-only transaction rather
+// than a caller's, so results are committed data that callers must not modify.
+type CachedCategoryRepository struct {
+	CategoryRepository
+	db    *sql.DB
+	cache *cache.GenerationalCache
+	group singleflight.Group
+}
+
+func NewCachedCategoryRepository(db *sql.DB, repository CategoryRepository, categoryCache *cache.GenerationalCache) *CachedCategoryRepository {
+	return &CachedCategoryRepository{
+		CategoryRepository: repository,
+		db:                 db,
+		cache:              categoryCache,
+	}
+}
+
+func (repository *CachedCategoryRepository) FindCategoryByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Category, error) {
+	key := categoryCacheKey + strconv.FormatUint(id, 10)
+
+	var cate models.Category
+	if repository.get(ctx, key, &cate) {
+		return &cate, nil
+	}
+
+	return load(ctx, repository, key, func(ctx context.Context, tx *sql.Tx) (*models.Category, error) {
+		return repository.CategoryRepository.FindCategoryByID(ctx, tx, id)
+	})
+}
+
+func (repository *CachedCategoryRepository) ListCategoryOfBook(ctx context.Context, tx *sql.Tx, bookID uint64) ([]*models.Category, error) {
+	key := bookCategoryCacheKey + strconv.FormatUint(bookID, 10)
+
+	var categories []*models.Category
+	if repository.get(ctx, key, &categories) {
+		return categories, nil
+	}
+
+	return load(ctx, repository, key, func(ctx context.Context, tx *sql.Tx) ([]*models.Category, error) {
+		return repository.CategoryRepository.ListCategoryOfBook(ctx, tx, bookID)
+	})
+}
+
+func (repository *CachedCategoryRepository) UpdateCategory(ctx context.Context, tx *sql.Tx, cate *models.Category) error {
+	err := repository.invalidate(ctx, tx, categoryCacheKey+strconv.FormatUint(cate.ID, 10), bookCategoryCacheKey+"*")
+	if err != nil {
+		return err
+	}
+	return repository.CategoryRepository.UpdateCategory(ctx, tx, cate)
+}
+
+func (repository *CachedCategoryRepository) DeleteCategory(ctx context.Context, tx *sql.Tx, id uint64) error {
+	err := repository.invalidate(ctx, tx, categoryCacheKey+strconv.FormatUint(id, 10), bookCategoryCacheKey+"*")
+	if err != nil {
+		return err
+	}
+	return repository.CategoryRepository.DeleteCategory(ctx, tx, id)
+}
+
+func (repository *CachedCategoryRepository) AddBookCategory(ctx context.Context, tx *sql.Tx, bookCate *models.BookCategory) error {
+	err := repository.invalidate(ctx, tx, bookCategoryCacheKey+strconv.FormatUint(bookCate.BookID, 10))
+	if err != nil {
+		return err
+	}
+	return repository.CategoryRepository.AddBookCategory(ctx, tx, bookCate)
+}
+
+func (repository *CachedCategoryRepository) RemoveBookCategory(ctx context.Context, tx *sql.Tx, bookCate *models.BookCategory) error {
+	err := repository.invalidate(ctx, tx, bookCategoryCacheKey+strconv.FormatUint(bookCate.BookID, 10))
+	if err != nil {
+		return err
+	}
+	return repository.CategoryRepository.RemoveBookCategory(ctx, tx, bookCate)
+}
+
+func (repository *CachedCategoryRepository) CacheStats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		"categories": repository.cache.Stats(),
+	}
+}
+
+// get reports whether key was cached and decoded into dest. Cache failures are
+// logged and treated as misses so reads fall back to the database.
+func (repository *CachedCategoryRepository) get(ctx context.Context, key string, dest interface{}) bool {
+	data, ok, err := repository.cache.Get(ctx, key)
+	if err != nil {
+		log.Printf("category cache: %v", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// set caches value under key unless the cache was invalidated after generation.
+// Failures are logged, the value is still served.
+func (repository *CachedCategoryRepository) set(ctx context.Context, generation uint64, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("category cache: %v", err)
+		return
+	}
+
+	_, err = repository.cache.SetIfGeneration(ctx, generation, key, data)
+	if err != nil {
+		log.Printf("category cache: %v", err)
+	}
+}
+
+// load runs fn once for all concurrent misses on key within a cache generation
+// and caches its result. fn gets a read-only transaction and a context detached
+// from the callers, so the caller that started the load can finish or give up
+// without failing the others; each caller still returns when its own context
+// is done.
+func load[T any](ctx context.Context, repository *CachedCategoryRepository, key string, fn func(ctx context.Context, tx *sql.Tx) (T, error)) (T, error) {
+	generation := repository.cache.Generation()
+	shared := repository.group.DoChan(key+"@"+strconv.FormatUint(generation, 10), func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheLoadTimeout)
+		defer cancel()
+
+		tx, err := repository.db.BeginTx(loadCtx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		value, err := fn(loadCtx, tx)
+		if err != nil {
+			return nil, err
+		}
+		repository.set(loadCtx, generation, key, value)
+		return value, nil
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case result := <-shared:
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		return result.Val.(T), nil
+	}
+}
+
+// invalidate drops keys now and notifies every replica to drop them again on commit.
+// A key ending in "*" is a prefix.
+func (repository *CachedCategoryRepository) invalidate(ctx context.Context, tx *sql.Tx, keys ...string) error {
+	for _, key := range keys {
+		var err error
+		if prefix, ok := strings.CutSuffix(key, "*"); ok {
+			err = repository.cache.DeletePrefix(ctx, prefix)
+		} else {
+			err = repository.cache.Delete(ctx, key)
+		}
+		if err != nil {
+			log.Printf("category cache: %v", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, cache.InvalidationChannel, key)
+		if err != nil {
+			return errors.New("Failed to notify cache invalidation, transaction rolled back. Reason: " + err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"library-api-category/internal/cache"
+	"library-api-category/internal/models"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestCachedCategoryRepositoryRedisDown(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	redisCache := cache.NewRedisCache(server.Addr(), "", 0, time.Minute)
+	defer redisCache.Close()
+
+	repository := NewCachedCategoryRepository(nil, NewCategoryRepository(), cache.NewGenerationalCache(redisCache))
+
+	repository.set(ctx, 0, categoryCacheKey+"1", &models.Category{ID: 1, Name: "Fiction"})
+
+	var cate models.Category
+	if !repository.get(ctx, categoryCacheKey+"1", &cate) {
+		t.Fatalf("get missed a cached category")
+	}
+	if cate.ID != 1 || cate.Name != "Fiction" {
+		t.Errorf("get = %+v, want category 1 Fiction", cate)
+	}
+
+	server.Close()
+
+	// a failing cache is a miss, so reads fall through to the database
+	if repository.get(ctx, categoryCacheKey+"1", &cate) {
+		t.Errorf("get reported a hit with Redis down")
+	}
+	repository.set(ctx, 0, categoryCacheKey+"2", &models.Category{ID: 2})
+}
+
+func TestCachedCategoryRepositoryLoadRacingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+
+	categoryCache := cache.NewGenerationalCache(cache.NewMemoryCache(10, time.Minute))
+	repository := NewCachedCategoryRepository(db, NewCategoryRepository(), categoryCache)
+	key := categoryCacheKey + "1"
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var loads atomic.Int32
+	fn := func(ctx context.Context, tx *sql.Tx) (*models.Category, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+			<-release
+			return &models.Category{ID: 1, Name: "Before"}, nil
+		}
+		return &models.Category{ID: 1, Name: "After"}, nil
+	}
+
+	stale := make(chan *models.Category)
+	go func() {
+		cate, _ := load(ctx, repository, key, fn)
+		stale <- cate
+	}()
+	<-started
+
+	// the write commits and its invalidation arrives while the first load runs
+	err = categoryCache.Delete(ctx, key)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	cate, err := load(ctx, repository, key, fn)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cate.Name != "After" {
+		t.Errorf("miss after the invalidation = %s, want it not to join the stale load", cate.Name)
+	}
+
+	close(release)
+	if cate := <-stale; cate.Name != "Before" {
+		t.Errorf("stale load = %s, want Before", cate.Name)
+	}
+
+	var cached models.Category
+	if !repository.get(ctx, key, &cached) || cached.Name != "After" {
+		t.Errorf("cached %+v, want the load that started after the invalidation", cached)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
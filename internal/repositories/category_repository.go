@@ -5,16 +5,26 @@ import (
 	"database/sql"
 	"errors"
 	"library-api-category/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type CategoryRepository interface {
 	CreateCategory(ctx context.Context, tx *sql.Tx, cate *models.Category) error
 	FindCategoryByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Category, error)
+	FindCategoriesByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.Category, error)
+	FindCategoryByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*models.Category, error)
 	UpdateCategory(ctx context.Context, tx *sql.Tx, cate *models.Category) error
 	DeleteCategory(ctx context.Context, tx *sql.Tx, id uint64) error
 	GetAllCategories(ctx context.Context, tx *sql.Tx, pagination *models.Pagination) ([]*models.Category, error)
 	AddBookCategory(ctx context.Context, tx *sql.Tx, bookCate *models.BookCategory) error
+	RemoveBookCategory(ctx context.Context, tx *sql.Tx, bookCate *models.BookCategory) error
 	ListCategoryOfBook(ctx context.Context, tx *sql.Tx, bookID uint64) ([]*models.Category, error)
+	ListCategoriesOfBooks(ctx context.Context, tx *sql.Tx, bookIDs []uint64) (map[uint64][]*models.Category, error)
+	CountBooksOfCategories(ctx context.Context, tx *sql.Tx, ids []uint64) (map[uint64]uint64, error)
+	ListAssignedBookIDs(ctx context.Context, tx *sql.Tx, afterID uint64, limit int) ([]uint64, error)
+	LockCategoryIDsOfBook(ctx context.Context, tx *sql.Tx, bookID uint64) ([]uint64, error)
+	RemoveBooksOfCategory(ctx context.Context, tx *sql.Tx, categoryID uint64) ([]uint64, error)
 }
 
 type CategoryRepositoryImpl struct {
@@ -25,9 +35,9 @@ func NewCategoryRepository() CategoryRepository {
 }
 
 func (repository *CategoryRepositoryImpl) CreateCategory(ctx context.Context, tx *sql.Tx, cate *models.Category) error {
-	query := `INSERT INTO categories (name, description, created_at, updated_at) VALUES ($1, $2, $3, $4)`
-	response, err := tx.ExecContext(ctx, query, cate.Name, cate.Description, cate.CreatedAt, cate.UpdatedAt)
-	if err != nil || response == nil {
+	query := `INSERT INTO categories (name, description, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	err := tx.QueryRowContext(ctx, query, cate.Name, cate.Description, cate.CreatedAt, cate.UpdatedAt).Scan(&cate.ID)
+	if err != nil {
 		return errors.New("Failed to create a category, transaction rolled back. Reason: " + err.Error())
 	}
 
@@ -54,6 +64,47 @@ func (repository *CategoryRepositoryImpl) FindCategoryByID(ctx context.Context,
 	}
 }
 
+func (repository *CategoryRepositoryImpl) FindCategoriesByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.Category, error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE id = ANY($1::bigint[]) ORDER BY id`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+	for rows.Next() {
+		var cate models.Category
+		err := rows.Scan(&cate.ID, &cate.Name, &cate.Description, &cate.CreatedAt, &cate.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, &cate)
+	}
+	return categories, rows.Err()
+}
+
+func (repository *CategoryRepositoryImpl) FindCategoryByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*models.Category, error) {
+	query := "SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1 FOR UPDATE"
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cate = models.Category{}
+	if rows.Next() {
+		err := rows.Scan(&cate.ID, &cate.Name, &cate.Description, &cate.CreatedAt, &cate.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		return &cate, nil
+	} else {
+		return nil, errors.New("category is not found")
+	}
+}
+
 func (repository *CategoryRepositoryImpl) UpdateCategory(ctx context.Context, tx *sql.Tx, cate *models.Category) error {
 	query := `UPDATE categories SET name = $1, description = $2, updated_at = $3 WHERE id = $4`
 
@@ -70,11 +121,11 @@ func (repository *CategoryRepositoryImpl) UpdateCategory(ctx context.Context, tx
 }
 
 func (repository *CategoryRepositoryImpl) DeleteCategory(ctx context.Context, tx *sql.Tx, id uint64) error {
-	SQL := `DELETE categories WHERE id = $1`
+	SQL := `DELETE FROM categories WHERE id = $1`
 
 	_, err := tx.ExecContext(ctx, SQL, id)
 	if err != nil {
-		return errors.New("Failed to update a category, transaction rolled back. Reason: " + err.Error())
+		return errors.New("Failed to delete a category, transaction rolled back. Reason: " + err.Error())
 	}
 	return nil
 }
@@ -108,15 +159,34 @@ func (repository *CategoryRepositoryImpl) AddBookCategory(ctx context.Context, t
 		return errors.New("Failed to create a book category, transaction rolled back. Reason: " + err.Error())
 	}
 
-	return nil
+	return repository.adjustBookCount(ctx, tx, bookCate.CategoryID, 1)
+}
+
+func (repository *CategoryRepositoryImpl) RemoveBookCategory(ctx context.Context, tx *sql.Tx, bookCate *models.BookCategory) error {
+	query := `DELETE FROM book_categories WHERE book_id = $1 AND category_id = $2`
+	result, err := tx.ExecContext(ctx, query, bookCate.BookID, bookCate.CategoryID)
+	if err != nil {
+		return errors.New("Failed to remove a book category, transaction rolled back. Reason: " + err.Error())
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("book category is not found")
+	}
+
+	return repository.adjustBookCount(ctx, tx, bookCate.CategoryID, -affected)
 }
 
 func (repository *CategoryRepositoryImpl) ListCategoryOfBook(ctx context.Context, tx *sql.Tx, bookID uint64) ([]*models.Category, error) {
 	query := `
-		SELECT c.id, c.name, c.description, c.created_at, c.updated_at 
+		SELECT c.id, c.name, c.description, c.created_at, c.updated_at
 		FROM book_categories bc
-		JOIN categories c ON bc.book_id = c.id
-		WHERE bc.book_id = $1`
+		JOIN categories c ON bc.category_id = c.id
+		WHERE bc.book_id = $1
+		ORDER BY c.name, c.id`
 	rows, err := tx.QueryContext(ctx, query, bookID)
 	if err != nil {
 		return nil, err
@@ -135,3 +205,120 @@ func (repository *CategoryRepositoryImpl) ListCategoryOfBook(ctx context.Context
 	}
 	return categories, nil
 }
+
+// ListCategoriesOfBooks returns the categories of every book in bookIDs, keyed
+// by book ID and ordered by name, in a single query. Books without categories
+// are left out of the map.
+func (repository *CategoryRepositoryImpl) ListCategoriesOfBooks(ctx context.Context, tx *sql.Tx, bookIDs []uint64) (map[uint64][]*models.Category, error) {
+	query := `
+		SELECT bc.book_id, c.id, c.name, c.description, c.created_at, c.updated_at
+		FROM book_categories bc
+		JOIN categories c ON bc.category_id = c.id
+		WHERE bc.book_id = ANY($1::bigint[])
+		ORDER BY bc.book_id, c.name, c.id`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(bookIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make(map[uint64][]*models.Category)
+	for rows.Next() {
+		var bookID uint64
+		var cate models.Category
+		err := rows.Scan(&bookID, &cate.ID, &cate.Name, &cate.Description, &cate.CreatedAt, &cate.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		categories[bookID] = append(categories[bookID], &cate)
+	}
+	return categories, rows.Err()
+}
+
+// CountBooksOfCategories returns the materialized number of books assigned to
+// each of ids. Categories without a count are left out of the map.
+func (repository *CategoryRepositoryImpl) CountBooksOfCategories(ctx context.Context, tx *sql.Tx, ids []uint64) (map[uint64]uint64, error) {
+	query := `SELECT category_id, book_count FROM category_book_counts WHERE category_id = ANY($1::bigint[])`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uint64]uint64, len(ids))
+	for rows.Next() {
+		var id, count uint64
+		err := rows.Scan(&id, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListAssignedBookIDs pages through the distinct books that have categories,
+// in ascending order after afterID.
+func (repository *CategoryRepositoryImpl) ListAssignedBookIDs(ctx context.Context, tx *sql.Tx, afterID uint64, limit int) ([]uint64, error) {
+	query := `SELECT DISTINCT book_id FROM book_categories WHERE book_id > $1 ORDER BY book_id LIMIT $2`
+	return repository.queryIDs(ctx, tx, query, afterID, limit)
+}
+
+// LockCategoryIDsOfBook returns the categories assigned to bookID and locks
+// the assignments until the transaction ends.
+func (repository *CategoryRepositoryImpl) LockCategoryIDsOfBook(ctx context.Context, tx *sql.Tx, bookID uint64) ([]uint64, error) {
+	query := `SELECT category_id FROM book_categories WHERE book_id = $1 ORDER BY category_id FOR UPDATE`
+	return repository.queryIDs(ctx, tx, query, bookID)
+}
+
+// RemoveBooksOfCategory unassigns every book from categoryID and returns the
+// books it removed in ascending order.
+func (repository *CategoryRepositoryImpl) RemoveBooksOfCategory(ctx context.Context, tx *sql.Tx, categoryID uint64) ([]uint64, error) {
+	query := `
+		WITH removed AS (DELETE FROM book_categories WHERE category_id = $1 RETURNING book_id)
+		SELECT book_id FROM removed ORDER BY book_id`
+	bookIDs, err := repository.queryIDs(ctx, tx, query, categoryID)
+	if err != nil {
+		return nil, errors.New("Failed to remove the books of a category, transaction rolled back. Reason: " + err.Error())
+	}
+
+	return bookIDs, repository.adjustBookCount(ctx, tx, categoryID, -int64(len(bookIDs)))
+}
+
+func (repository *CategoryRepositoryImpl) queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]uint64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// adjustBookCount keeps category_book_counts in step with book_categories in
+// the same transaction. Concurrent assignments to one category serialize on
+// its count row.
+func (repository *CategoryRepositoryImpl) adjustBookCount(ctx context.Context, tx *sql.Tx, categoryID uint64, delta int64) error {
+	query := `
+		INSERT INTO category_book_counts (category_id, book_count, updated_at)
+		VALUES ($1, GREATEST($2::bigint, 0), NOW())
+		ON CONFLICT (category_id) DO UPDATE
+		SET book_count = GREATEST(category_book_counts.book_count + $2::bigint, 0), updated_at = NOW()`
+	_, err := tx.ExecContext(ctx, query, categoryID, delta)
+	if err != nil {
+		return errors.New("Failed to update the book count of a category, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
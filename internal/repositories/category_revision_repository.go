@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/models"
+)
+
+type CategoryRevisionRepository interface {
+	CreateRevision(ctx context.Context, tx *sql.Tx, revision *models.CategoryRevision) error
+	LatestRevision(ctx context.Context, tx *sql.Tx, categoryID uint64) (uint64, error)
+	FindRevision(ctx context.Context, tx *sql.Tx, categoryID uint64, revision uint64) (*models.CategoryRevision, error)
+	ListRevisions(ctx context.Context, tx *sql.Tx, categoryID uint64) ([]*models.CategoryRevision, error)
+}
+
+type CategoryRevisionRepositoryImpl struct {
+}
+
+func NewCategoryRevisionRepository() CategoryRevisionRepository {
+	return &CategoryRevisionRepositoryImpl{}
+}
+
+// CreateRevision stores the snapshot as the next revision of the category. The
+// caller must hold the category row lock so revision numbers stay sequential.
+func (repository *CategoryRevisionRepositoryImpl) CreateRevision(ctx context.Context, tx *sql.Tx, revision *models.CategoryRevision) error {
+	query := `
+		INSERT INTO category_revisions (category_id, revision, name, description, actor_id, created_at)
+		SELECT $1::int, COALESCE(MAX(revision), 0) + 1, $2::varchar, $3::text, $4::int, $5::timestamp FROM category_revisions WHERE category_id = $1::int
+		RETURNING id, revision`
+	err := tx.QueryRowContext(ctx, query,
+		revision.CategoryID,
+		revision.Name,
+		revision.Description,
+		revision.ActorID,
+		revision.CreatedAt,
+	).Scan(&revision.ID, &revision.Revision)
+	if err != nil {
+		return errors.New("Failed to create a category revision, transaction rolled back. Reason: " + err.Error())
+	}
+
+	return nil
+}
+
+func (repository *CategoryRevisionRepositoryImpl) LatestRevision(ctx context.Context, tx *sql.Tx, categoryID uint64) (uint64, error) {
+	query := `SELECT COALESCE(MAX(revision), 0) FROM category_revisions WHERE category_id = $1`
+
+	var revision uint64
+	err := tx.QueryRowContext(ctx, query, categoryID).Scan(&revision)
+	if err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+func (repository *CategoryRevisionRepositoryImpl) FindRevision(ctx context.Context, tx *sql.Tx, categoryID uint64, revision uint64) (*models.CategoryRevision, error) {
+	query := `
+		SELECT id, category_id, revision, name, COALESCE(description, ''), actor_id, created_at
+		FROM category_revisions
+		WHERE category_id = $1 AND revision = $2`
+
+	var rev models.CategoryRevision
+	err := tx.QueryRowContext(ctx, query, categoryID, revision).Scan(&rev.ID, &rev.CategoryID, &rev.Revision, &rev.Name, &rev.Description, &rev.ActorID, &rev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("category revision is not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func (repository *CategoryRevisionRepositoryImpl) ListRevisions(ctx context.Context, tx *sql.Tx, categoryID uint64) ([]*models.CategoryRevision, error) {
+	query := `
+		SELECT id, category_id, revision, name, COALESCE(description, ''), actor_id, created_at
+		FROM category_revisions
+		WHERE category_id = $1
+		ORDER BY revision DESC`
+	rows, err := tx.QueryContext(ctx, query, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*models.CategoryRevision
+	for rows.Next() {
+		var rev models.CategoryRevision
+		err := rows.Scan(&rev.ID, &rev.CategoryID, &rev.Revision, &rev.Name, &rev.Description, &rev.ActorID, &rev.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, &rev)
+	}
+	return revisions, rows.Err()
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/models"
+	"time"
+)
+
+type CategoryStatsRepository interface {
+	GetTotals(ctx context.Context, tx *sql.Tx) (*models.CategoryStats, error)
+	ListEmptyCategories(ctx context.Context, tx *sql.Tx, limit int) ([]*models.CategoryUsage, error)
+	ListMostUsed(ctx context.Context, tx *sql.Tx, limit int) ([]*models.CategoryUsage, error)
+	ListLeastUsed(ctx context.Context, tx *sql.Tx, limit int) ([]*models.CategoryUsage, error)
+	CountAssignmentsPerDay(ctx context.Context, tx *sql.Tx, since time.Time) ([]*models.DailyAssignments, error)
+	FindCountDrift(ctx context.Context, tx *sql.Tx) ([]*models.CategoryCountDrift, error)
+	RecomputeBookCounts(ctx context.Context, tx *sql.Tx) (int64, error)
+}
+
+type CategoryStatsRepositoryImpl struct {
+}
+
+func NewCategoryStatsRepository() CategoryStatsRepository {
+	return &CategoryStatsRepositoryImpl{}
+}
+
+func (repository *CategoryStatsRepositoryImpl) GetTotals(ctx context.Context, tx *sql.Tx) (*models.CategoryStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM categories),
+			(SELECT COALESCE(SUM(book_count), 0) FROM category_book_counts),
+			(SELECT COUNT(DISTINCT book_id) FROM book_categories),
+			(SELECT COUNT(*) FROM categories c LEFT JOIN category_book_counts cbc ON cbc.category_id = c.id WHERE COALESCE(cbc.book_count, 0) = 0)`
+
+	var stats models.CategoryStats
+	err := tx.QueryRowContext(ctx, query).Scan(&stats.TotalCategories, &stats.TotalAssignments, &stats.CategorizedBooks, &stats.EmptyCategories)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (repository *CategoryStatsRepositoryImpl) ListEmptyCategories(ctx context.Context, tx *sql.Tx, limit int) ([]*models.CategoryUsage, error) {
+	query := `
+		SELECT c.id, c.name, 0
+		FROM categories c
+		LEFT JOIN category_book_counts cbc ON cbc.category_id = c.id
+		WHERE COALESCE(cbc.book_count, 0) = 0
+		ORDER BY c.name, c.id
+		LIMIT $1`
+	return repository.listUsage(ctx, tx, query, limit)
+}
+
+func (repository *CategoryStatsRepositoryImpl) ListMostUsed(ctx context.Context, tx *sql.Tx, limit int) ([]*models.CategoryUsage, error) {
+	query := `
+		SELECT c.id, c.name, cbc.book_count
+		FROM category_book_counts cbc
+		JOIN categories c ON c.id = cbc.category_id
+		WHERE cbc.book_count > 0
+		ORDER BY cbc.book_count DESC, c.id
+		LIMIT $1`
+	return repository.listUsage(ctx, tx, query, limit)
+}
+
+// ListLeastUsed only considers categories with at least one book, empty
+// categories are reported by ListEmptyCategories.
+func (repository *CategoryStatsRepositoryImpl) ListLeastUsed(ctx context.Context, tx *sql.Tx, limit int) ([]*models.CategoryUsage, error) {
+	query := `
+		SELECT c.id, c.name, cbc.book_count
+		FROM category_book_counts cbc
+		JOIN categories c ON c.id = cbc.category_id
+		WHERE cbc.book_count > 0
+		ORDER BY cbc.book_count, c.id
+		LIMIT $1`
+	return repository.listUsage(ctx, tx, query, limit)
+}
+
+func (repository *CategoryStatsRepositoryImpl) CountAssignmentsPerDay(ctx context.Context, tx *sql.Tx, since time.Time) ([]*models.DailyAssignments, error) {
+	query := `
+		SELECT date_trunc('day', created_at) AS day, COUNT(*)
+		FROM book_categories
+		WHERE created_at >= $1
+		GROUP BY day
+		ORDER BY day`
+	rows, err := tx.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*models.DailyAssignments
+	for rows.Next() {
+		var day models.DailyAssignments
+		err := rows.Scan(&day.Day, &day.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		days = append(days, &day)
+	}
+	return days, rows.Err()
+}
+
+// FindCountDrift compares every materialized book count with the assignments
+// it is derived from. Categories get a count row with their first assignment,
+// so a missing row counts as 0 like everywhere else.
+func (repository *CategoryStatsRepositoryImpl) FindCountDrift(ctx context.Context, tx *sql.Tx) ([]*models.CategoryCountDrift, error) {
+	query := `
+		SELECT c.id, COALESCE(cbc.book_count, 0), COALESCE(actual.book_count, 0)
+		FROM categories c
+		LEFT JOIN category_book_counts cbc ON cbc.category_id = c.id
+		LEFT JOIN (
+			SELECT category_id, COUNT(*) AS book_count
+			FROM book_categories
+			GROUP BY category_id
+		) actual ON actual.category_id = c.id
+		WHERE COALESCE(cbc.book_count, 0) <> COALESCE(actual.book_count, 0)
+		ORDER BY c.id`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drifts []*models.CategoryCountDrift
+	for rows.Next() {
+		var drift models.CategoryCountDrift
+		err := rows.Scan(&drift.CategoryID, &drift.StoredCount, &drift.ActualCount)
+		if err != nil {
+			return nil, err
+		}
+
+		drifts = append(drifts, &drift)
+	}
+	return drifts, rows.Err()
+}
+
+// RecomputeBookCounts rebuilds every count from book_categories. Writers are
+// held off with a SHARE lock for the rest of the transaction so the counts
+// are exact when it commits.
+func (repository *CategoryStatsRepositoryImpl) RecomputeBookCounts(ctx context.Context, tx *sql.Tx) (int64, error) {
+	_, err := tx.ExecContext(ctx, `LOCK TABLE book_categories IN SHARE MODE`)
+	if err != nil {
+		return 0, errors.New("Failed to lock book categories, transaction rolled back. Reason: " + err.Error())
+	}
+
+	query := `
+		INSERT INTO category_book_counts (category_id, book_count, updated_at)
+		SELECT c.id, COUNT(bc.book_id), NOW()
+		FROM categories c
+		LEFT JOIN book_categories bc ON bc.category_id = c.id
+		GROUP BY c.id
+		ON CONFLICT (category_id) DO UPDATE
+		SET book_count = EXCLUDED.book_count, updated_at = EXCLUDED.updated_at
+		WHERE category_book_counts.book_count <> EXCLUDED.book_count`
+	result, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		return 0, errors.New("Failed to recompute book counts, transaction rolled back. Reason: " + err.Error())
+	}
+	return result.RowsAffected()
+}
+
+func (repository *CategoryStatsRepositoryImpl) listUsage(ctx context.Context, tx *sql.Tx, query string, limit int) ([]*models.CategoryUsage, error) {
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []*models.CategoryUsage
+	for rows.Next() {
+		var usage models.CategoryUsage
+		err := rows.Scan(&usage.CategoryID, &usage.Name, &usage.BookCount)
+		if err != nil {
+			return nil, err
+		}
+
+		usages = append(usages, &usage)
+	}
+	return usages, rows.Err()
+}
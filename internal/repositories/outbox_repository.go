@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/models"
+	"strconv"
+)
+
+type OutboxRepository interface {
+	CreateEvent(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error
+	ListEventsAfter(ctx context.Context, tx *sql.Tx, afterID uint64, limit int) ([]*models.OutboxEvent, error)
+	LatestEventID(ctx context.Context, tx *sql.Tx) (uint64, error)
+	FetchPendingEvents(ctx context.Context, tx *sql.Tx, limit int) ([]*models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, tx *sql.Tx, id uint64) error
+	MarkFailed(ctx context.Context, tx *sql.Tx, id uint64, reason string) error
+	MarkDead(ctx context.Context, tx *sql.Tx, id uint64, reason string) error
+}
+
+// OutboxChannel is the Postgres NOTIFY channel carrying the id of every new
+// outbox event. Notifications are only delivered once the transaction commits.
+const OutboxChannel = "outbox_events"
+
+type OutboxRepositoryImpl struct {
+}
+
+func NewOutboxRepository() OutboxRepository {
+	return &OutboxRepositoryImpl{}
+}
+
+func (repository *OutboxRepositoryImpl) CreateEvent(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query,
+		event.EventType,
+		event.AggregateType,
+		event.AggregateID,
+		string(event.Payload),
+		event.CreatedAt,
+	).Scan(&event.ID)
+	if err != nil {
+		return errors.New("Failed to create an outbox event, transaction rolled back. Reason: " + err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, OutboxChannel, strconv.FormatUint(event.ID, 10))
+	if err != nil {
+		return errors.New("Failed to notify an outbox event, transaction rolled back. Reason: " + err.Error())
+	}
+
+	return nil
+}
+
+func (repository *OutboxRepositoryImpl) ListEventsAfter(ctx context.Context, tx *sql.Tx, afterID uint64, limit int) ([]*models.OutboxEvent, error) {
+	query := `
+		SELECT id, event_type, aggregate_type, aggregate_id, payload, attempts, created_at
+		FROM outbox_events
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`
+	rows, err := tx.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+// LatestEventID returns the id of the newest committed event, or 0 when the
+// outbox is empty.
+func (repository *OutboxRepositoryImpl) LatestEventID(ctx context.Context, tx *sql.Tx) (uint64, error) {
+	var id uint64
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM outbox_events`).Scan(&id)
+	return id, err
+}
+
+// FetchPendingEvents locks the oldest unpublished events so concurrent relays
+// on other replicas skip them instead of publishing twice. Dead events are left
+// out.
+func (repository *OutboxRepositoryImpl) FetchPendingEvents(ctx context.Context, tx *sql.Tx, limit int) ([]*models.OutboxEvent, error) {
+	query := `
+		SELECT id, event_type, aggregate_type, aggregate_id, payload, attempts, created_at
+		FROM outbox_events
+		WHERE published_at IS NULL AND dead_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+func scanOutboxEvents(rows *sql.Rows) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.EventType, &event.AggregateType, &event.AggregateID, &payload, &event.Attempts, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		event.Payload = payload
+
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+func (repository *OutboxRepositoryImpl) MarkPublished(ctx context.Context, tx *sql.Tx, id uint64) error {
+	query := `UPDATE outbox_events SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`
+
+	_, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.New("Failed to mark an outbox event as published. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *OutboxRepositoryImpl) MarkFailed(ctx context.Context, tx *sql.Tx, id uint64, reason string) error {
+	query := `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1 WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, reason, id)
+	if err != nil {
+		return errors.New("Failed to mark an outbox event as failed. Reason: " + err.Error())
+	}
+	return nil
+}
+
+// MarkDead parks an event that exhausted its attempts. The relay skips it
+// until dead_at is cleared.
+func (repository *OutboxRepositoryImpl) MarkDead(ctx context.Context, tx *sql.Tx, id uint64, reason string) error {
+	query := `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, dead_at = NOW() WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, reason, id)
+	if err != nil {
+		return errors.New("Failed to mark an outbox event as dead. Reason: " + err.Error())
+	}
+	return nil
+}
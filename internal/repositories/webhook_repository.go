@@ -0,0 +1,251 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"library-api-category/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, tx *sql.Tx, sub *models.WebhookSubscription) error
+	FindSubscriptionByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.WebhookSubscription, error)
+	GetAllSubscriptions(ctx context.Context, tx *sql.Tx) ([]*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, tx *sql.Tx, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, tx *sql.Tx, id uint64) error
+	EnqueueDeliveries(ctx context.Context, tx *sql.Tx, delivery *models.WebhookDelivery) (int64, error)
+	FetchDueDeliveries(ctx context.Context, tx *sql.Tx, limit int) ([]*models.WebhookDelivery, error)
+	LeaseDeliveries(ctx context.Context, tx *sql.Tx, ids []uint64, until time.Time) error
+	UpdateDelivery(ctx context.Context, tx *sql.Tx, delivery *models.WebhookDelivery) error
+	GetAllDeliveries(ctx context.Context, tx *sql.Tx, filter *models.WebhookDeliveryFilter, pagination *models.Pagination) ([]*models.WebhookDelivery, error)
+	RedeliverDelivery(ctx context.Context, tx *sql.Tx, id uint64) error
+}
+
+type WebhookRepositoryImpl struct {
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &WebhookRepositoryImpl{}
+}
+
+func (repository *WebhookRepositoryImpl) CreateSubscription(ctx context.Context, tx *sql.Tx, sub *models.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	err := tx.QueryRowContext(ctx, query, sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.Active, sub.CreatedAt, sub.UpdatedAt).Scan(&sub.ID)
+	if err != nil {
+		return errors.New("Failed to create a webhook subscription, transaction rolled back. Reason: " + err.Error())
+	}
+
+	return nil
+}
+
+func (repository *WebhookRepositoryImpl) FindSubscriptionByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.WebhookSubscription, error) {
+	query := `SELECT id, url, secret, event_types, active, created_at, updated_at FROM webhook_subscriptions WHERE id = $1`
+
+	var sub models.WebhookSubscription
+	err := tx.QueryRowContext(ctx, query, id).Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&sub.EventTypes), &sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("webhook subscription is not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (repository *WebhookRepositoryImpl) GetAllSubscriptions(ctx context.Context, tx *sql.Tx) ([]*models.WebhookSubscription, error) {
+	query := `SELECT id, url, secret, event_types, active, created_at, updated_at FROM webhook_subscriptions ORDER BY id`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*models.WebhookSubscription
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&sub.EventTypes), &sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, &sub)
+	}
+	return subs, rows.Err()
+}
+
+func (repository *WebhookRepositoryImpl) UpdateSubscription(ctx context.Context, tx *sql.Tx, sub *models.WebhookSubscription) error {
+	query := `UPDATE webhook_subscriptions SET url = $1, secret = $2, event_types = $3, active = $4, updated_at = $5 WHERE id = $6`
+
+	_, err := tx.ExecContext(ctx, query, sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.Active, sub.UpdatedAt, sub.ID)
+	if err != nil {
+		return errors.New("Failed to update a webhook subscription, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *WebhookRepositoryImpl) DeleteSubscription(ctx context.Context, tx *sql.Tx, id uint64) error {
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	_, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.New("Failed to delete a webhook subscription, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+// EnqueueDeliveries creates a pending delivery of the event for every active
+// subscription whose filter matches. Enqueueing the same event twice is a no-op.
+func (repository *WebhookRepositoryImpl) EnqueueDeliveries(ctx context.Context, tx *sql.Tx, delivery *models.WebhookDelivery) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, created_at, next_attempt_at)
+		SELECT id, $1::bigint, $2::varchar, $3::jsonb, $4::timestamp, $4::timestamp
+		FROM webhook_subscriptions
+		WHERE active AND (cardinality(event_types) = 0 OR $2::text = ANY(event_types))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	result, err := tx.ExecContext(ctx, query, delivery.EventID, delivery.EventType, string(delivery.Payload), delivery.CreatedAt)
+	if err != nil {
+		return 0, errors.New("Failed to enqueue webhook deliveries. Reason: " + err.Error())
+	}
+	return result.RowsAffected()
+}
+
+// FetchDueDeliveries locks pending deliveries whose retry time has passed,
+// skipping rows another dispatcher is already working on.
+func (repository *WebhookRepositoryImpl) FetchDueDeliveries(ctx context.Context, tx *sql.Tx, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.created_at, s.url, s.secret
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.active
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $1
+		FOR UPDATE OF d SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var payload []byte
+		err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.URL, &delivery.Secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload = payload
+
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// LeaseDeliveries pushes next_attempt_at of claimed deliveries to until, so
+// other dispatchers leave them alone while they are sent outside the claiming
+// transaction. A dispatcher that dies mid-send lets the lease run out and the
+// deliveries are picked up again.
+func (repository *WebhookRepositoryImpl) LeaseDeliveries(ctx context.Context, tx *sql.Tx, ids []uint64, until time.Time) error {
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id = ANY($2)`
+
+	_, err := tx.ExecContext(ctx, query, until, pq.Array(ids))
+	if err != nil {
+		return errors.New("Failed to lease webhook deliveries. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *WebhookRepositoryImpl) UpdateDelivery(ctx context.Context, tx *sql.Tx, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = NULLIF($4, 0), last_error = NULLIF($5, ''), delivered_at = $6
+		WHERE id = $7`
+
+	_, err := tx.ExecContext(ctx, query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+	if err != nil {
+		return errors.New("Failed to update a webhook delivery. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *WebhookRepositoryImpl) GetAllDeliveries(ctx context.Context, tx *sql.Tx, filter *models.WebhookDeliveryFilter, pagination *models.Pagination) ([]*models.WebhookDelivery, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != 0 {
+		args = append(args, filter.SubscriptionID)
+		conditions = append(conditions, fmt.Sprintf("subscription_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_deliveries"+where, args...).Scan(&pagination.TotalCount)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, subscription_id, event_id, event_type, status, attempts, next_attempt_at, COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, delivered_at
+		FROM webhook_deliveries%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	rows, err := tx.QueryContext(ctx, query, append(args, pagination.PageSize, pagination.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RedeliverDelivery puts a dead or delivered delivery back in the queue with a fresh retry budget.
+func (repository *WebhookRepositoryImpl) RedeliverDelivery(ctx context.Context, tx *sql.Tx, id uint64) error {
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL WHERE id = $1`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.New("Failed to redeliver a webhook delivery. Reason: " + err.Error())
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("webhook delivery is not found")
+	}
+	return nil
+}
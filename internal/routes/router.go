@@ -2,19 +2,35 @@ package routes
 
 import (
 	"fmt"
+	"library-api-category/internal/config"
 	"library-api-category/internal/factory"
 	"library-api-category/internal/grpc/client"
 	"library-api-category/internal/middleware"
+	"library-api-category/internal/policy"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(provider *factory.Provider, authClient *client.AuthClient) *gin.Engine {
+func RegisterRoutes(provider *factory.Provider, authClient client.TokenValidator) *gin.Engine {
 	router := gin.New()
 
-	router.Use(gin.Logger(), CORS())
+	// Without trusted proxies ClientIP is the peer address, so clients cannot
+	// pick their own rate limit bucket with X-Forwarded-For.
+	if err := router.SetTrustedProxies(config.ENV.TrustedProxies); err != nil {
+		log.Fatalf("Failed to parse TRUSTED_PROXIES: %v", err)
+	}
+
+	router.Use(gin.Logger(), middleware.CORS(middleware.CORSConfig{
+		AllowedOrigins:   config.ENV.CORSAllowedOrigins,
+		AllowedMethods:   config.ENV.CORSAllowedMethods,
+		AllowedHeaders:   config.ENV.CORSAllowedHeaders,
+		ExposedHeaders:   config.ENV.CORSExposedHeaders,
+		AllowCredentials: config.ENV.CORSAllowCredentials,
+		MaxAge:           config.ENV.CORSMaxAge,
+	}), middleware.RequestID())
 
 	router.GET("/", func(ctx *gin.Context) {
 		currentYear := time.Now().Year()
@@ -27,31 +43,65 @@ func RegisterRoutes(provider *factory.Provider, authClient *client.AuthClient) *
 	{
 		v1 := api.Group("v1")
 		{
-			auth := v1.Use(middleware.CheckAuth(authClient))
-			auth.GET("/categories", provider.CategoryProvider.GetAllCategories)
-			auth.GET("/categories/:id", provider.CategoryProvider.GetDetailCategory)
-			auth.GET("/categories/books/:id", provider.CategoryProvider.ListCategoryOfBook)
-
-			admin := v1.Use(middleware.CheckAuthIsAdminOrAuthor(authClient))
-			admin.POST("/categories", provider.CategoryProvider.CreateCategory)
-			admin.PUT("/categories/:id", provider.CategoryProvider.UpdateCategory)
-			admin.DELETE("/categories/:id", provider.CategoryProvider.DeleteCategory)
-			admin.POST("/categories/books", provider.CategoryProvider.AddBookCategory)
-		}
-	}
+			can := func(permission string) gin.HandlerFunc {
+				return middleware.Authorize(provider.Policy, permission)
+			}
 
-	return router
-}
+			// Groups below are created with their middleware instead of calling
+			// Use on a shared group, so each route carries exactly the checks
+			// listed for it.
+			rateLimit := middleware.RateLimit(provider.RateLimiter, provider.RateLimitRules)
+			authenticated := v1.Group("", middleware.Authenticate(authClient, provider.APIKeys), rateLimit)
+
+			catalog := authenticated.Group("", can(policy.CategoryRead))
+			if config.ENV.PublicReadRoutes {
+				catalog = v1.Group("", middleware.OptionalAuthenticate(authClient, provider.APIKeys), rateLimit)
+			}
+			catalog.GET("/categories", middleware.HTTPCache(config.ENV.HTTPCacheCategories, config.ENV.PublicReadRoutes), provider.CategoryProvider.GetAllCategories)
+			catalog.GET("/categories/:id", middleware.HTTPCache(config.ENV.HTTPCacheCategoryDetail, config.ENV.PublicReadRoutes), provider.CategoryProvider.GetDetailCategory)
+			catalog.GET("/categories/books", middleware.HTTPCache(config.ENV.HTTPCacheBookCategories, config.ENV.PublicReadRoutes), provider.CategoryProvider.ListCategoriesOfBooks)
+			catalog.GET("/categories/books/:id", middleware.HTTPCache(config.ENV.HTTPCacheBookCategories, config.ENV.PublicReadRoutes), provider.CategoryProvider.ListCategoryOfBook)
 
-func CORS() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		ctx.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS, POST, PUT, DELETE")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, accept, access-control-allow-origin, access-control-allow-headers")
-		if ctx.Request.Method == "OPTIONS" {
-			ctx.AbortWithStatus(http.StatusNoContent)
+			categories := authenticated.Group("/categories")
+			categories.GET("/stream", can(policy.CategoryRead), provider.StreamProvider.StreamCategoryEvents)
+			categories.GET("/stats", can(policy.StatsRead), provider.StatsProvider.GetCategoryStats)
+			categories.GET("/:id/revisions", can(policy.CategoryRead), provider.CategoryProvider.ListCategoryRevisions)
+			categories.GET("/:id/revisions/diff", can(policy.CategoryRead), provider.CategoryProvider.DiffCategoryRevisions)
+			categories.POST("", can(policy.CategoryCreate), provider.CategoryProvider.CreateCategory)
+			categories.PUT("/:id", can(policy.CategoryUpdate), provider.CategoryProvider.UpdateCategory)
+			categories.DELETE("/:id", can(policy.CategoryDelete), provider.CategoryProvider.DeleteCategory)
+			categories.POST("/:id/revisions/:revision/revert", can(policy.CategoryRevert), provider.CategoryProvider.RevertCategory)
+			categories.POST("/books", can(policy.AssignmentWrite), provider.CategoryProvider.AddBookCategory)
+			categories.DELETE("/:id/books/:book_id", can(policy.AssignmentWrite), provider.CategoryProvider.RemoveBookCategory)
+
+			authenticated.GET("/audits", can(policy.AuditRead), provider.AuditProvider.GetAllAuditLogs)
+			authenticated.GET("/cache/stats", can(policy.CacheRead), provider.CacheProvider.GetCacheStats)
+
+			auth := authenticated.Group("/auth")
+			auth.POST("/revocations", can(policy.TokenRevoke), provider.TokenProvider.RevokeTokens)
+			auth.GET("/policy", can(policy.PolicyRead), provider.PolicyProvider.GetPolicy)
+			auth.POST("/policy/reload", can(policy.PolicyReload), provider.PolicyProvider.ReloadPolicy)
+
+			apiKeys := authenticated.Group("/api-keys", can(policy.APIKeyManage))
+			apiKeys.POST("", provider.APIKeyProvider.CreateAPIKey)
+			apiKeys.GET("", provider.APIKeyProvider.GetAllAPIKeys)
+			apiKeys.DELETE("/:id", provider.APIKeyProvider.RevokeAPIKey)
+
+			reconciliations := authenticated.Group("/reconciliations", can(policy.ReconcileRun))
+			reconciliations.POST("/orphans", provider.ReconcileProvider.TriggerOrphanReconciliation)
+			reconciliations.GET("/orphans", provider.ReconcileProvider.GetOrphanReconciliation)
+
+			webhooks := authenticated.Group("/webhooks", can(policy.WebhookManage))
+			webhooks.POST("", provider.WebhookProvider.CreateSubscription)
+			webhooks.GET("", provider.WebhookProvider.GetAllSubscriptions)
+			webhooks.GET("/dead-letters", provider.WebhookProvider.GetDeadLetters)
+			webhooks.POST("/deliveries/:id/redeliver", provider.WebhookProvider.RedeliverDelivery)
+			webhooks.GET("/:id", provider.WebhookProvider.GetDetailSubscription)
+			webhooks.PUT("/:id", provider.WebhookProvider.UpdateSubscription)
+			webhooks.DELETE("/:id", provider.WebhookProvider.DeleteSubscription)
+			webhooks.GET("/:id/deliveries", provider.WebhookProvider.GetSubscriptionDeliveries)
 		}
-		ctx.Next()
 	}
+
+	return router
 }
@@ -0,0 +1,346 @@
+package routes
+
+import (
+	"context"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/config"
+	"library-api-category/internal/controllers"
+	"library-api-category/internal/factory"
+	"library-api-category/internal/models"
+	"library-api-category/internal/policy"
+	"library-api-category/internal/ratelimit"
+	"library-api-category/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tkn "library-api-category/pkg/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubController answers every route with 200, so the status seen by a test
+// comes from the authentication and authorization middleware alone.
+type stubController struct{}
+
+func (stubController) ok(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+
+func (c stubController) CreateCategory(ctx *gin.Context)              { c.ok(ctx) }
+func (c stubController) GetDetailCategory(ctx *gin.Context)           { c.ok(ctx) }
+func (c stubController) UpdateCategory(ctx *gin.Context)              { c.ok(ctx) }
+func (c stubController) DeleteCategory(ctx *gin.Context)              { c.ok(ctx) }
+func (c stubController) GetAllCategories(ctx *gin.Context)            { c.ok(ctx) }
+func (c stubController) AddBookCategory(ctx *gin.Context)             { c.ok(ctx) }
+func (c stubController) RemoveBookCategory(ctx *gin.Context)          { c.ok(ctx) }
+func (c stubController) ListCategoryOfBook(ctx *gin.Context)          { c.ok(ctx) }
+func (c stubController) ListCategoriesOfBooks(ctx *gin.Context)       { c.ok(ctx) }
+func (c stubController) ListCategoryRevisions(ctx *gin.Context)       { c.ok(ctx) }
+func (c stubController) DiffCategoryRevisions(ctx *gin.Context)       { c.ok(ctx) }
+func (c stubController) RevertCategory(ctx *gin.Context)              { c.ok(ctx) }
+func (c stubController) GetAllAuditLogs(ctx *gin.Context)             { c.ok(ctx) }
+func (c stubController) GetCategoryStats(ctx *gin.Context)            { c.ok(ctx) }
+func (c stubController) GetCacheStats(ctx *gin.Context)               { c.ok(ctx) }
+func (c stubController) RevokeTokens(ctx *gin.Context)                { c.ok(ctx) }
+func (c stubController) CreateAPIKey(ctx *gin.Context)                { c.ok(ctx) }
+func (c stubController) GetAllAPIKeys(ctx *gin.Context)               { c.ok(ctx) }
+func (c stubController) RevokeAPIKey(ctx *gin.Context)                { c.ok(ctx) }
+func (c stubController) GetPolicy(ctx *gin.Context)                   { c.ok(ctx) }
+func (c stubController) ReloadPolicy(ctx *gin.Context)                { c.ok(ctx) }
+func (c stubController) StreamCategoryEvents(ctx *gin.Context)        { c.ok(ctx) }
+func (c stubController) TriggerOrphanReconciliation(ctx *gin.Context) { c.ok(ctx) }
+func (c stubController) GetOrphanReconciliation(ctx *gin.Context)     { c.ok(ctx) }
+func (c stubController) CreateSubscription(ctx *gin.Context)          { c.ok(ctx) }
+func (c stubController) GetAllSubscriptions(ctx *gin.Context)         { c.ok(ctx) }
+func (c stubController) GetDetailSubscription(ctx *gin.Context)       { c.ok(ctx) }
+func (c stubController) UpdateSubscription(ctx *gin.Context)          { c.ok(ctx) }
+func (c stubController) DeleteSubscription(ctx *gin.Context)          { c.ok(ctx) }
+func (c stubController) GetSubscriptionDeliveries(ctx *gin.Context)   { c.ok(ctx) }
+func (c stubController) GetDeadLetters(ctx *gin.Context)              { c.ok(ctx) }
+func (c stubController) RedeliverDelivery(ctx *gin.Context)           { c.ok(ctx) }
+
+var (
+	_ controllers.CategoryController       = stubController{}
+	_ controllers.AuditController          = stubController{}
+	_ controllers.CategoryStatsController  = stubController{}
+	_ controllers.CacheController          = stubController{}
+	_ controllers.TokenController          = stubController{}
+	_ controllers.APIKeyController         = stubController{}
+	_ controllers.PolicyController         = stubController{}
+	_ controllers.StreamController         = stubController{}
+	_ controllers.ReconciliationController = stubController{}
+	_ controllers.WebhookController        = stubController{}
+)
+
+type stubTokenValidator map[string]tkn.Token
+
+func (validator stubTokenValidator) ValidateToken(ctx context.Context, token string) (*tkn.Token, error) {
+	payload, ok := validator[token]
+	if !ok {
+		return nil, tkn.ErrInvalidToken
+	}
+	return &payload, nil
+}
+
+// stubAPIKeys knows a single key that may only read categories.
+type stubAPIKeys struct {
+	services.APIKeyService
+}
+
+func (stubAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, *response.CustomError) {
+	if key != "lak_reader" {
+		return nil, response.UnauthorizedError("Invalid api key")
+	}
+	return &models.APIKey{ID: 1, Permissions: []string{policy.CategoryRead}}, nil
+}
+
+type caller struct {
+	name   string
+	header string
+	value  string
+}
+
+var (
+	anonymous = caller{name: "anonymous"}
+	user      = caller{name: "user", header: "Authorization", value: "Bearer user-token"}
+	author    = caller{name: "author", header: "Authorization", value: "Bearer author-token"}
+	admin     = caller{name: "admin", header: "Authorization", value: "Bearer admin-token"}
+	apiKey    = caller{name: "api key", header: "X-API-Key", value: "lak_reader"}
+
+	callers = []caller{anonymous, user, author, admin, apiKey}
+)
+
+var validator = stubTokenValidator{
+	"user-token":   {AuthId: 1, Role: "user"},
+	"author-token": {AuthId: 2, Role: "author"},
+	"admin-token":  {AuthId: 3, Role: "admin"},
+}
+
+type routeCase struct {
+	method  string
+	path    string
+	route   string
+	allowed []caller
+}
+
+// routeCases lists every route with the callers the default policy lets in.
+func routeCases() []routeCase {
+	readers := []caller{user, author, admin, apiKey}
+	staff := []caller{author, admin}
+	admins := []caller{admin}
+
+	return []routeCase{
+		{"GET", "/api/v1/categories", "/api/v1/categories", readers},
+		{"GET", "/api/v1/categories/1", "/api/v1/categories/:id", readers},
+		{"GET", "/api/v1/categories/books?book_ids=1", "/api/v1/categories/books", readers},
+		{"GET", "/api/v1/categories/books/1", "/api/v1/categories/books/:id", readers},
+		{"GET", "/api/v1/categories/stream", "/api/v1/categories/stream", readers},
+		{"GET", "/api/v1/categories/1/revisions", "/api/v1/categories/:id/revisions", readers},
+		{"GET", "/api/v1/categories/1/revisions/diff", "/api/v1/categories/:id/revisions/diff", readers},
+		{"GET", "/api/v1/categories/stats", "/api/v1/categories/stats", admins},
+		{"POST", "/api/v1/categories", "/api/v1/categories", staff},
+		{"PUT", "/api/v1/categories/1", "/api/v1/categories/:id", staff},
+		{"DELETE", "/api/v1/categories/1", "/api/v1/categories/:id", staff},
+		{"POST", "/api/v1/categories/1/revisions/2/revert", "/api/v1/categories/:id/revisions/:revision/revert", admins},
+		{"POST", "/api/v1/categories/books", "/api/v1/categories/books", staff},
+		{"DELETE", "/api/v1/categories/1/books/2", "/api/v1/categories/:id/books/:book_id", staff},
+		{"GET", "/api/v1/audits", "/api/v1/audits", admins},
+		{"GET", "/api/v1/cache/stats", "/api/v1/cache/stats", admins},
+		{"POST", "/api/v1/auth/revocations", "/api/v1/auth/revocations", admins},
+		{"GET", "/api/v1/auth/policy", "/api/v1/auth/policy", admins},
+		{"POST", "/api/v1/auth/policy/reload", "/api/v1/auth/policy/reload", admins},
+		{"POST", "/api/v1/api-keys", "/api/v1/api-keys", admins},
+		{"GET", "/api/v1/api-keys", "/api/v1/api-keys", admins},
+		{"DELETE", "/api/v1/api-keys/1", "/api/v1/api-keys/:id", admins},
+		{"POST", "/api/v1/reconciliations/orphans", "/api/v1/reconciliations/orphans", admins},
+		{"GET", "/api/v1/reconciliations/orphans", "/api/v1/reconciliations/orphans", admins},
+		{"POST", "/api/v1/webhooks", "/api/v1/webhooks", admins},
+		{"GET", "/api/v1/webhooks", "/api/v1/webhooks", admins},
+		{"GET", "/api/v1/webhooks/dead-letters", "/api/v1/webhooks/dead-letters", admins},
+		{"POST", "/api/v1/webhooks/deliveries/1/redeliver", "/api/v1/webhooks/deliveries/:id/redeliver", admins},
+		{"GET", "/api/v1/webhooks/1", "/api/v1/webhooks/:id", admins},
+		{"PUT", "/api/v1/webhooks/1", "/api/v1/webhooks/:id", admins},
+		{"DELETE", "/api/v1/webhooks/1", "/api/v1/webhooks/:id", admins},
+		{"GET", "/api/v1/webhooks/1/deliveries", "/api/v1/webhooks/:id/deliveries", admins},
+	}
+}
+
+func newTestRouter(t *testing.T, publicReadRoutes bool, configure ...func(*factory.Provider)) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	previous := config.ENV
+	config.ENV = &config.Config{PublicReadRoutes: publicReadRoutes}
+	t.Cleanup(func() { config.ENV = previous })
+
+	engine, err := policy.NewEngine("")
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	stub := stubController{}
+	provider := &factory.Provider{
+		CategoryProvider:  stub,
+		AuditProvider:     stub,
+		StatsProvider:     stub,
+		WebhookProvider:   stub,
+		CacheProvider:     stub,
+		TokenProvider:     stub,
+		APIKeyProvider:    stub,
+		PolicyProvider:    stub,
+		StreamProvider:    stub,
+		ReconcileProvider: stub,
+		APIKeys:           stubAPIKeys{},
+		Policy:            engine,
+	}
+	for _, fn := range configure {
+		fn(provider)
+	}
+	return RegisterRoutes(provider, validator)
+}
+
+func serve(router *gin.Engine, method string, path string, from caller) int {
+	req := httptest.NewRequest(method, path, nil)
+	if from.header != "" {
+		req.Header.Set(from.header, from.value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestRoutesCoverEveryRoute(t *testing.T) {
+	router := newTestRouter(t, false)
+
+	covered := map[string]bool{}
+	for _, tc := range routeCases() {
+		covered[tc.method+" "+tc.route] = true
+	}
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		if !covered[route.Method+" "+route.Path] {
+			t.Errorf("%s %s is missing from routeCases", route.Method, route.Path)
+		}
+	}
+}
+
+func TestRoutesAuthorization(t *testing.T) {
+	router := newTestRouter(t, false)
+
+	for _, tc := range routeCases() {
+		for _, from := range callers {
+			want := http.StatusForbidden
+			switch {
+			case from == anonymous:
+				want = http.StatusUnauthorized
+			case containsCaller(tc.allowed, from):
+				want = http.StatusOK
+			}
+
+			t.Run(tc.method+" "+tc.route+" as "+from.name, func(t *testing.T) {
+				if got := serve(router, tc.method, tc.path, from); got != want {
+					t.Errorf("status = %d, want %d", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestRoutesRejectBadCredentials(t *testing.T) {
+	router := newTestRouter(t, false)
+
+	for _, from := range []caller{
+		{name: "unknown token", header: "Authorization", value: "Bearer forged"},
+		{name: "malformed header", header: "Authorization", value: "Token user-token"},
+		{name: "unknown api key", header: "X-API-Key", value: "lak_forged"},
+	} {
+		t.Run(from.name, func(t *testing.T) {
+			if got := serve(router, "GET", "/api/v1/categories", from); got != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", got, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRoutesPublicRead(t *testing.T) {
+	router := newTestRouter(t, true)
+
+	public := map[string]bool{
+		"GET /api/v1/categories":           true,
+		"GET /api/v1/categories/:id":       true,
+		"GET /api/v1/categories/books":     true,
+		"GET /api/v1/categories/books/:id": true,
+	}
+	for _, tc := range routeCases() {
+		want := http.StatusUnauthorized
+		if public[tc.method+" "+tc.route] {
+			want = http.StatusOK
+		}
+
+		t.Run(tc.method+" "+tc.route, func(t *testing.T) {
+			if got := serve(router, tc.method, tc.path, anonymous); got != want {
+				t.Errorf("anonymous status = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRoutesPublicReadAuthenticatesCredentials(t *testing.T) {
+	router := newTestRouter(t, true)
+
+	for _, from := range []caller{
+		{name: "unknown token", header: "Authorization", value: "Bearer forged"},
+		{name: "unknown api key", header: "X-API-Key", value: "lak_forged"},
+	} {
+		t.Run(from.name, func(t *testing.T) {
+			if got := serve(router, "GET", "/api/v1/categories", from); got != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", got, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRoutesPublicReadRateLimitsByRole(t *testing.T) {
+	router := newTestRouter(t, true, func(provider *factory.Provider) {
+		provider.RateLimiter = ratelimit.NewMemoryLimiter()
+		provider.RateLimitRules = &ratelimit.Rules{
+			Default: ratelimit.Limit{Rate: 0.001, Burst: 3},
+			Roles: map[string]ratelimit.Limit{
+				ratelimit.AnonymousRole: {Rate: 0.001, Burst: 1},
+			},
+		}
+	})
+
+	tests := []struct {
+		from    caller
+		allowed int
+	}{
+		{from: anonymous, allowed: 1},
+		{from: user, allowed: 3},
+		{from: apiKey, allowed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from.name, func(t *testing.T) {
+			for i := 0; i < tt.allowed; i++ {
+				if got := serve(router, "GET", "/api/v1/categories", tt.from); got != http.StatusOK {
+					t.Fatalf("request %d status = %d, want 200", i+1, got)
+				}
+			}
+			if got := serve(router, "GET", "/api/v1/categories", tt.from); got != http.StatusTooManyRequests {
+				t.Errorf("status = %d, want 429", got)
+			}
+		})
+	}
+}
+
+func containsCaller(callers []caller, from caller) bool {
+	for _, c := range callers {
+		if c == from {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/models"
+	"library-api-category/internal/params"
+	"library-api-category/internal/repositories"
+	"time"
+)
+
+type AuditService interface {
+	GetAllAuditLogs(ctx context.Context, filter *models.AuditFilter, pagination *models.Pagination) ([]*params.AuditLogResponse, *response.CustomError)
+}
+
+type AuditServiceImpl struct {
+	DB                 *sql.DB
+	AuditLogRepository repositories.AuditLogRepository
+}
+
+func NewAuditService(db *sql.DB, AuditLogRepository repositories.AuditLogRepository) AuditService {
+	return &AuditServiceImpl{
+		DB:                 db,
+		AuditLogRepository: AuditLogRepository,
+	}
+}
+
+func (service *AuditServiceImpl) GetAllAuditLogs(ctx context.Context, filter *models.AuditFilter, pagination *models.Pagination) (_ []*params.AuditLogResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	pagination.Offset = (pagination.Page - 1) * pagination.PageSize
+
+	audits, err := service.AuditLogRepository.GetAllAuditLogs(ctx, tx, filter, pagination)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch audit logs: " + err.Error())
+	}
+
+	auditResponses := make([]*params.AuditLogResponse, len(audits))
+	for i, audit := range audits {
+		auditResponses[i] = &params.AuditLogResponse{
+			ID:         audit.ID,
+			ActorID:    audit.ActorID,
+			ActorRole:  audit.ActorRole,
+			Action:     audit.Action,
+			EntityType: audit.EntityType,
+			EntityID:   audit.EntityID,
+			Before:     audit.Before,
+			After:      audit.After,
+			RequestID:  audit.RequestID,
+			CreatedAt:  audit.CreatedAt,
+		}
+	}
+
+	pagination.PageCount = (pagination.TotalCount + pagination.PageSize - 1) / pagination.PageSize
+
+	return auditResponses, nil
+}
+
+// newAuditLog builds an audit entry for the caller stored in ctx by the auth
+// and request id middlewares. A nil before or after is stored as NULL.
+func newAuditLog(ctx context.Context, action string, entityType string, entityID uint64, before interface{}, after interface{}) (*models.AuditLog, error) {
+	audit := &models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		CreatedAt:  time.Now(),
+	}
+
+	audit.ActorID, audit.ActorRole = actorFromContext(ctx)
+	if requestId, ok := ctx.Value("requestId").(string); ok {
+		audit.RequestID = requestId
+	}
+
+	var err error
+	if before != nil {
+		audit.Before, err = json.Marshal(before)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		audit.After, err = json.Marshal(after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return audit, nil
+}
+
+// actorFromContext returns the caller id and role stored by the auth middleware.
+// For API keys the id is the key's id.
+func actorFromContext(ctx context.Context) (uint64, string) {
+	var actorID uint64
+	if authId, ok := ctx.Value("authId").(int); ok {
+		actorID = uint64(authId)
+	}
+	if apiKeyId, ok := ctx.Value("apiKeyId").(uint64); ok {
+		actorID = apiKeyId
+	}
+	role, _ := ctx.Value("role").(string)
+
+	return actorID, role
+}
@@ -3,48 +3,76 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"library-api-category/internal/commons/response"
+	"library-api-category/internal/grpc/client"
 	"library-api-category/internal/models"
 	"library-api-category/internal/params"
+	"library-api-category/internal/policy"
 	"library-api-category/internal/repositories"
+	"log"
+	"slices"
+	"sort"
 	"time"
 )
 
 type CategoryService interface {
 	CreateCategory(ctx context.Context, req *params.CategoryRequest) *response.CustomError
-	GetDetailCategory(ctx context.Context, id uint64) (*params.CategoryResponse, *response.CustomError)
+	GetDetailCategory(ctx context.Context, id uint64, includeCounts bool) (*params.CategoryResponse, *response.CustomError)
 	UpdateCategory(ctx context.Context, id uint64, req *params.CategoryRequest) *response.CustomError
 	DeleteCategory(ctx context.Context, id uint64) *response.CustomError
-	GetAllCategories(ctx context.Context, pagination *models.Pagination) ([]*params.CategoryResponse, *response.CustomError)
+	BatchGetCategories(ctx context.Context, ids []uint64) (*params.BatchCategoriesResponse, *response.CustomError)
+	GetAllCategories(ctx context.Context, pagination *models.Pagination, includeCounts bool) ([]*params.CategoryResponse, *response.CustomError)
 	AddBookCategory(ctx context.Context, req *params.BookCategoryRequest) *response.CustomError
+	RemoveBookCategory(ctx context.Context, req *params.BookCategoryRequest) *response.CustomError
+	RemoveAllBookCategories(ctx context.Context, bookID uint64) (int, *response.CustomError)
 	ListCategoryOfBook(ctx context.Context, bookID uint64) ([]*params.CategoryResponse, *response.CustomError)
+	ListCategoriesOfBooks(ctx context.Context, bookIDs []uint64) (map[uint64][]*params.CategoryResponse, *response.CustomError)
+	ListCategoryRevisions(ctx context.Context, id uint64) ([]*params.CategoryRevisionResponse, *response.CustomError)
+	DiffCategoryRevisions(ctx context.Context, id uint64, from uint64, to uint64) (*params.CategoryRevisionDiffResponse, *response.CustomError)
+	RevertCategory(ctx context.Context, id uint64, revision uint64) *response.CustomError
 }
 
+// MaxBatchSize caps the number of IDs accepted by a single batch lookup.
+const MaxBatchSize = 100
+
 type CategoryServiceImpl struct {
-	DB                 *sql.DB
-	CategoryRepository repositories.CategoryRepository
+	DB                         *sql.DB
+	CategoryRepository         repositories.CategoryRepository
+	AuditLogRepository         repositories.AuditLogRepository
+	CategoryRevisionRepository repositories.CategoryRevisionRepository
+	OutboxRepository           repositories.OutboxRepository
+	// BookLookup verifies books before they are assigned, nil skips the check.
+	BookLookup client.BookLookup
+	// StrictBookLookup rejects assignments while the book service is down
+	// instead of accepting them unverified.
+	StrictBookLookup bool
+	// Policy decides which roles may assign categories to books they do not
+	// author, nil leaves assignments unrestricted.
+	Policy *policy.Engine
 }
 
-func NewCategoryService(db *sql.DB, CategoryRepository repositories.CategoryRepository) CategoryService {
+func NewCategoryService(db *sql.DB, CategoryRepository repositories.CategoryRepository, AuditLogRepository repositories.AuditLogRepository, CategoryRevisionRepository repositories.CategoryRevisionRepository, OutboxRepository repositories.OutboxRepository, BookLookup client.BookLookup, StrictBookLookup bool, Policy *policy.Engine) CategoryService {
 	return &CategoryServiceImpl{
-		DB:                 db,
-		CategoryRepository: CategoryRepository,
+		DB:                         db,
+		CategoryRepository:         CategoryRepository,
+		AuditLogRepository:         AuditLogRepository,
+		CategoryRevisionRepository: CategoryRevisionRepository,
+		OutboxRepository:           OutboxRepository,
+		BookLookup:                 BookLookup,
+		StrictBookLookup:           StrictBookLookup,
+		Policy:                     Policy,
 	}
 }
 
-func (service *CategoryServiceImpl) CreateCategory(ctx context.Context, req *params.CategoryRequest) *response.CustomError {
+func (service *CategoryServiceImpl) CreateCategory(ctx context.Context, req *params.CategoryRequest) (custErr *response.CustomError) {
 	tx, err := service.DB.Begin()
 	if err != nil {
 		return response.GeneralError("Failed Connection to database errors: " + err.Error())
 	}
-	defer func() {
-		err := recover()
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer endTx(tx, &custErr)
 
 	var cate = models.Category{
 		Name:        req.Name,
@@ -59,22 +87,30 @@ func (service *CategoryServiceImpl) CreateCategory(ctx context.Context, req *par
 		return response.GeneralError(err.Error())
 	}
 
+	err = service.recordAudit(ctx, tx, models.AuditActionCategoryCreate, models.AuditEntityCategory, cate.ID, nil, toCategoryResponse(&cate))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordEvent(ctx, tx, models.EventCategoryCreated, models.AggregateCategory, cate.ID, toCategoryResponse(&cate))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordRevision(ctx, tx, &cate)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
 	return nil
 }
 
-func (service *CategoryServiceImpl) GetDetailCategory(ctx context.Context, id uint64) (*params.CategoryResponse, *response.CustomError) {
+func (service *CategoryServiceImpl) GetDetailCategory(ctx context.Context, id uint64, includeCounts bool) (_ *params.CategoryResponse, custErr *response.CustomError) {
 	tx, err := service.DB.Begin()
 	if err != nil {
 		return nil, response.GeneralError("Failed Connection to database errors: " + err.Error())
 	}
-	defer func() {
-		err := recover()
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer endTx(tx, &custErr)
 
 	cate, err := service.CategoryRepository.FindCategoryByID(ctx, tx, id)
 	if err != nil {
@@ -89,70 +125,131 @@ func (service *CategoryServiceImpl) GetDetailCategory(ctx context.Context, id ui
 		UpdatedAt:   cate.UpdatedAt,
 	}
 
+	if includeCounts {
+		err = service.attachBookCounts(ctx, tx, cateResponse)
+		if err != nil {
+			return nil, response.GeneralError("Failed to count books of category: " + err.Error())
+		}
+	}
+
 	return cateResponse, nil
 }
 
-func (service *CategoryServiceImpl) UpdateCategory(ctx context.Context, id uint64, req *params.CategoryRequest) *response.CustomError {
+func (service *CategoryServiceImpl) UpdateCategory(ctx context.Context, id uint64, req *params.CategoryRequest) (custErr *response.CustomError) {
 	tx, err := service.DB.Begin()
 	if err != nil {
 		return response.GeneralError("Failed to connect to the database: " + err.Error())
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer endTx(tx, &custErr)
+
+	before, err := service.CategoryRepository.FindCategoryByIDForUpdate(ctx, tx, id)
+	if err != nil {
+		return response.NotFoundError("Category not found")
+	}
 
 	book := models.Category{
 		ID:          id,
 		Name:        req.Name,
 		Description: req.Description,
+		CreatedAt:   before.CreatedAt,
 		UpdatedAt:   time.Now(),
 	}
 
+	latest, err := service.CategoryRevisionRepository.LatestRevision(ctx, tx, id)
+	if err != nil {
+		return response.GeneralError("Failed to fetch category revisions: " + err.Error())
+	}
+	if latest == 0 {
+		// categories created before revisions existed get their current state as revision 1
+		err = service.recordRevision(ctx, tx, before)
+		if err != nil {
+			return response.GeneralError(err.Error())
+		}
+	}
+
 	err = service.CategoryRepository.UpdateCategory(ctx, tx, &book)
 	if err != nil {
 		return response.GeneralError("Failed to update category: " + err.Error())
 	}
 
+	err = service.recordRevision(ctx, tx, &book)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordAudit(ctx, tx, models.AuditActionCategoryUpdate, models.AuditEntityCategory, id, toCategoryResponse(before), toCategoryResponse(&book))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordEvent(ctx, tx, models.EventCategoryUpdated, models.AggregateCategory, id, toCategoryResponse(&book))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
 	return nil
 }
 
-func (service *CategoryServiceImpl) DeleteCategory(ctx context.Context, id uint64) *response.CustomError {
+func (service *CategoryServiceImpl) DeleteCategory(ctx context.Context, id uint64) (custErr *response.CustomError) {
 	tx, err := service.DB.Begin()
 	if err != nil {
 		return response.GeneralError("Failed to connect to the database: " + err.Error())
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+	defer endTx(tx, &custErr)
+
+	before, err := service.CategoryRepository.FindCategoryByIDForUpdate(ctx, tx, id)
+	if err != nil {
+		return response.NotFoundError("Category not found")
+	}
+
+	// unassign the books first so every assignment gets its own audit entry
+	// and event instead of disappearing with the category
+	bookIDs, err := service.CategoryRepository.RemoveBooksOfCategory(ctx, tx, id)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	for _, bookID := range bookIDs {
+		req := &params.BookCategoryRequest{
+			BookID:     bookID,
+			CategoryID: id,
 		}
-	}()
+
+		err = service.recordAudit(ctx, tx, models.AuditActionBookCategoryRemove, models.AuditEntityBookCategory, bookID, req, nil)
+		if err != nil {
+			return response.GeneralError(err.Error())
+		}
+
+		err = service.recordEvent(ctx, tx, models.EventBookCategoryRemoved, models.AggregateBook, bookID, req)
+		if err != nil {
+			return response.GeneralError(err.Error())
+		}
+	}
 
 	err = service.CategoryRepository.DeleteCategory(ctx, tx, id)
 	if err != nil {
 		return response.GeneralError("Failed to delete category: " + err.Error())
 	}
 
+	err = service.recordAudit(ctx, tx, models.AuditActionCategoryDelete, models.AuditEntityCategory, id, toCategoryResponse(before), nil)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordEvent(ctx, tx, models.EventCategoryDeleted, models.AggregateCategory, id, toCategoryResponse(before))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
 	return nil
 }
 
-func (service *CategoryServiceImpl) GetAllCategories(ctx context.Context, pagination *models.Pagination) ([]*params.CategoryResponse, *response.CustomError) {
+func (service *CategoryServiceImpl) GetAllCategories(ctx context.Context, pagination *models.Pagination, includeCounts bool) (_ []*params.CategoryResponse, custErr *response.CustomError) {
 	tx, err := service.DB.Begin()
 	if err != nil {
 		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer endTx(tx, &custErr)
 
 	pagination.Offset = (pagination.Page - 1) * pagination.PageSize
 
@@ -172,24 +269,29 @@ func (service *CategoryServiceImpl) GetAllCategories(ctx context.Context, pagina
 		}
 	}
 
+	if includeCounts {
+		err = service.attachBookCounts(ctx, tx, cateResponses...)
+		if err != nil {
+			return nil, response.GeneralError("Failed to count books of categories: " + err.Error())
+		}
+	}
+
 	pagination.PageCount = (pagination.TotalCount + pagination.PageSize - 1) / pagination.PageSize
 
 	return cateResponses, nil
 }
 
-func (service *CategoryServiceImpl) AddBookCategory(ctx context.Context, req *params.BookCategoryRequest) *response.CustomError {
+func (service *CategoryServiceImpl) AddBookCategory(ctx context.Context, req *params.BookCategoryRequest) (custErr *response.CustomError) {
+	custErr = service.verifyBook(ctx, req.BookID, true)
+	if custErr != nil {
+		return custErr
+	}
+
 	tx, err := service.DB.Begin()
 	if err != nil {
 		return response.GeneralError("Failed Connection to database errors: " + err.Error())
 	}
-	defer func() {
-		err := recover()
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer endTx(tx, &custErr)
 
 	var bookCate = models.BookCategory{
 		CategoryID: req.CategoryID,
@@ -202,21 +304,99 @@ func (service *CategoryServiceImpl) AddBookCategory(ctx context.Context, req *pa
 		return response.GeneralError(err.Error())
 	}
 
+	err = service.recordAudit(ctx, tx, models.AuditActionBookCategoryAdd, models.AuditEntityBookCategory, bookCate.BookID, nil, req)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordEvent(ctx, tx, models.EventBookCategoryAssigned, models.AggregateBook, bookCate.BookID, req)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
 	return nil
 }
 
-func (service *CategoryServiceImpl) ListCategoryOfBook(ctx context.Context, bookID uint64) ([]*params.CategoryResponse, *response.CustomError) {
+func (service *CategoryServiceImpl) RemoveBookCategory(ctx context.Context, req *params.BookCategoryRequest) (custErr *response.CustomError) {
+	custErr = service.verifyBook(ctx, req.BookID, false)
+	if custErr != nil {
+		return custErr
+	}
+
 	tx, err := service.DB.Begin()
 	if err != nil {
-		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+		return response.GeneralError("Failed Connection to database errors: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	var bookCate = models.BookCategory{
+		CategoryID: req.CategoryID,
+		BookID:     req.BookID,
+	}
+
+	err = service.CategoryRepository.RemoveBookCategory(ctx, tx, &bookCate)
+	if err != nil {
+		return response.NotFoundError("Book category not found")
+	}
+
+	err = service.recordAudit(ctx, tx, models.AuditActionBookCategoryRemove, models.AuditEntityBookCategory, bookCate.BookID, req, nil)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordEvent(ctx, tx, models.EventBookCategoryRemoved, models.AggregateBook, bookCate.BookID, req)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	return nil
+}
+
+// RemoveAllBookCategories unassigns bookID from every category, with an audit
+// entry and an event per assignment, and returns how many were removed.
+func (service *CategoryServiceImpl) RemoveAllBookCategories(ctx context.Context, bookID uint64) (_ int, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return 0, response.GeneralError("Failed Connection to database errors: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	categoryIDs, err := service.CategoryRepository.LockCategoryIDsOfBook(ctx, tx, bookID)
+	if err != nil {
+		return 0, response.GeneralError("Failed to fetch categories of book: " + err.Error())
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+
+	for _, categoryID := range categoryIDs {
+		req := &params.BookCategoryRequest{
+			BookID:     bookID,
+			CategoryID: categoryID,
+		}
+
+		err = service.CategoryRepository.RemoveBookCategory(ctx, tx, &models.BookCategory{BookID: bookID, CategoryID: categoryID})
+		if err != nil {
+			return 0, response.GeneralError(err.Error())
 		}
-	}()
+
+		err = service.recordAudit(ctx, tx, models.AuditActionBookCategoryRemove, models.AuditEntityBookCategory, bookID, req, nil)
+		if err != nil {
+			return 0, response.GeneralError(err.Error())
+		}
+
+		err = service.recordEvent(ctx, tx, models.EventBookCategoryRemoved, models.AggregateBook, bookID, req)
+		if err != nil {
+			return 0, response.GeneralError(err.Error())
+		}
+	}
+
+	return len(categoryIDs), nil
+}
+
+func (service *CategoryServiceImpl) ListCategoryOfBook(ctx context.Context, bookID uint64) (_ []*params.CategoryResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
 
 	categories, err := service.CategoryRepository.ListCategoryOfBook(ctx, tx, bookID)
 	if err != nil {
@@ -236,3 +416,338 @@ func (service *CategoryServiceImpl) ListCategoryOfBook(ctx context.Context, book
 
 	return cateResponses, nil
 }
+
+// BatchGetCategories returns the categories with the given IDs ordered by ID,
+// and the IDs that do not exist.
+func (service *CategoryServiceImpl) BatchGetCategories(ctx context.Context, ids []uint64) (_ *params.BatchCategoriesResponse, custErr *response.CustomError) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, response.BadRequestError("at least one category id is required")
+	}
+	if len(ids) > MaxBatchSize {
+		return nil, response.BadRequestError(fmt.Sprintf("at most %d category ids are allowed per request", MaxBatchSize))
+	}
+
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	categories, err := service.CategoryRepository.FindCategoriesByIDs(ctx, tx, ids)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch categories: " + err.Error())
+	}
+
+	result := &params.BatchCategoriesResponse{
+		Categories: make([]*params.CategoryResponse, len(categories)),
+		MissingIDs: []uint64{},
+	}
+	found := make(map[uint64]bool, len(categories))
+	for i, cate := range categories {
+		result.Categories[i] = toCategoryResponse(cate)
+		found[cate.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			result.MissingIDs = append(result.MissingIDs, id)
+		}
+	}
+
+	return result, nil
+}
+
+// ListCategoriesOfBooks returns the categories of every requested book. Books
+// without categories map to an empty list so callers can tell them apart from
+// books they did not ask for.
+func (service *CategoryServiceImpl) ListCategoriesOfBooks(ctx context.Context, bookIDs []uint64) (_ map[uint64][]*params.CategoryResponse, custErr *response.CustomError) {
+	bookIDs = uniqueIDs(bookIDs)
+	if len(bookIDs) == 0 {
+		return nil, response.BadRequestError("at least one book id is required")
+	}
+	if len(bookIDs) > MaxBatchSize {
+		return nil, response.BadRequestError(fmt.Sprintf("at most %d book ids are allowed per request", MaxBatchSize))
+	}
+
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	categories, err := service.CategoryRepository.ListCategoriesOfBooks(ctx, tx, bookIDs)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch list book categories: " + err.Error())
+	}
+
+	result := make(map[uint64][]*params.CategoryResponse, len(bookIDs))
+	for _, bookID := range bookIDs {
+		cateResponses := make([]*params.CategoryResponse, len(categories[bookID]))
+		for i, cate := range categories[bookID] {
+			cateResponses[i] = toCategoryResponse(cate)
+		}
+		result[bookID] = cateResponses
+	}
+
+	return result, nil
+}
+
+func (service *CategoryServiceImpl) ListCategoryRevisions(ctx context.Context, id uint64) (_ []*params.CategoryRevisionResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	revisions, err := service.CategoryRevisionRepository.ListRevisions(ctx, tx, id)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch category revisions: " + err.Error())
+	}
+
+	// revisions outlive their category, so only a category without any is unknown
+	if len(revisions) == 0 {
+		_, err = service.CategoryRepository.FindCategoryByID(ctx, tx, id)
+		if err != nil {
+			return nil, response.NotFoundError("Category not found")
+		}
+	}
+
+	revResponses := make([]*params.CategoryRevisionResponse, len(revisions))
+	for i, rev := range revisions {
+		revResponses[i] = toCategoryRevisionResponse(rev)
+	}
+
+	return revResponses, nil
+}
+
+func (service *CategoryServiceImpl) DiffCategoryRevisions(ctx context.Context, id uint64, from uint64, to uint64) (_ *params.CategoryRevisionDiffResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	fromRev, err := service.CategoryRevisionRepository.FindRevision(ctx, tx, id, from)
+	if err != nil {
+		return nil, response.NotFoundError("Category revision not found")
+	}
+
+	toRev, err := service.CategoryRevisionRepository.FindRevision(ctx, tx, id, to)
+	if err != nil {
+		return nil, response.NotFoundError("Category revision not found")
+	}
+
+	diff := &params.CategoryRevisionDiffResponse{
+		CategoryID: id,
+		From:       from,
+		To:         to,
+		Changes:    []params.CategoryFieldChange{},
+	}
+	if fromRev.Name != toRev.Name {
+		diff.Changes = append(diff.Changes, params.CategoryFieldChange{Field: "name", From: fromRev.Name, To: toRev.Name})
+	}
+	if fromRev.Description != toRev.Description {
+		diff.Changes = append(diff.Changes, params.CategoryFieldChange{Field: "description", From: fromRev.Description, To: toRev.Description})
+	}
+
+	return diff, nil
+}
+
+func (service *CategoryServiceImpl) RevertCategory(ctx context.Context, id uint64, revision uint64) (custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	before, err := service.CategoryRepository.FindCategoryByIDForUpdate(ctx, tx, id)
+	if err != nil {
+		return response.NotFoundError("Category not found")
+	}
+
+	rev, err := service.CategoryRevisionRepository.FindRevision(ctx, tx, id, revision)
+	if err != nil {
+		return response.NotFoundError("Category revision not found")
+	}
+
+	cate := models.Category{
+		ID:          id,
+		Name:        rev.Name,
+		Description: rev.Description,
+		CreatedAt:   before.CreatedAt,
+		UpdatedAt:   time.Now(),
+	}
+
+	err = service.CategoryRepository.UpdateCategory(ctx, tx, &cate)
+	if err != nil {
+		return response.GeneralError("Failed to revert category: " + err.Error())
+	}
+
+	err = service.recordRevision(ctx, tx, &cate)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordAudit(ctx, tx, models.AuditActionCategoryRevert, models.AuditEntityCategory, id, toCategoryResponse(before), toCategoryResponse(&cate))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	err = service.recordEvent(ctx, tx, models.EventCategoryUpdated, models.AggregateCategory, id, toCategoryResponse(&cate))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+
+	return nil
+}
+
+func (service *CategoryServiceImpl) recordRevision(ctx context.Context, tx *sql.Tx, cate *models.Category) error {
+	actorID, _ := actorFromContext(ctx)
+
+	return service.CategoryRevisionRepository.CreateRevision(ctx, tx, &models.CategoryRevision{
+		CategoryID:  cate.ID,
+		Name:        cate.Name,
+		Description: cate.Description,
+		ActorID:     actorID,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func (service *CategoryServiceImpl) recordAudit(ctx context.Context, tx *sql.Tx, action string, entityType string, entityID uint64, before interface{}, after interface{}) error {
+	audit, err := newAuditLog(ctx, action, entityType, entityID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return service.AuditLogRepository.CreateAuditLog(ctx, tx, audit)
+}
+
+// recordEvent writes a domain event to the outbox inside the caller's transaction,
+// the relay publishes it once the transaction commits.
+func (service *CategoryServiceImpl) recordEvent(ctx context.Context, tx *sql.Tx, eventType string, aggregateType string, aggregateID uint64, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return service.OutboxRepository.CreateEvent(ctx, tx, &models.OutboxEvent{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       payload,
+		CreatedAt:     time.Now(),
+	})
+}
+
+// verifyBook checks bookID against the book service before its categories
+// change, outside of any transaction so no connection is held while waiting.
+// mustExist rejects unknown books; callers limited to their own books are
+// always checked and are rejected whenever the owner cannot be confirmed.
+// API keys author no books, so they need AssignmentWriteAny.
+func (service *CategoryServiceImpl) verifyBook(ctx context.Context, bookID uint64, mustExist bool) *response.CustomError {
+	if scopes, ok := ctx.Value("scopes").([]string); ok && !slices.Contains(scopes, policy.AssignmentWriteAny) {
+		return response.ForbiddenError("api key lacks permission " + policy.AssignmentWriteAny)
+	}
+
+	ownBooksOnly := service.ownBooksOnly(ctx)
+	if !ownBooksOnly && (!mustExist || service.BookLookup == nil) {
+		return nil
+	}
+	if service.BookLookup == nil {
+		return response.ServiceUnavailableError("Failed to verify the book owner: book service is not configured")
+	}
+
+	book, err := service.BookLookup.GetBook(ctx, bookID)
+	if errors.Is(err, client.ErrBookNotFound) {
+		return response.ResourceNotFoundError(fmt.Sprintf("book %d is not found", bookID))
+	}
+	if err != nil {
+		if ownBooksOnly {
+			return response.ServiceUnavailableError("Failed to verify the book owner: " + err.Error())
+		}
+		if service.StrictBookLookup {
+			return response.ServiceUnavailableError("Failed to verify the book: " + err.Error())
+		}
+		log.Printf("book lookup failed, assigning book %d unverified: %v", bookID, err)
+		return nil
+	}
+
+	actorID, _ := actorFromContext(ctx)
+	if ownBooksOnly && book.AuthorID != actorID {
+		return response.ForbiddenError(fmt.Sprintf("book %d is not authored by you", bookID))
+	}
+	return nil
+}
+
+// ownBooksOnly reports whether the caller may only change the categories of
+// books they author. API keys reaching it hold AssignmentWriteAny.
+func (service *CategoryServiceImpl) ownBooksOnly(ctx context.Context) bool {
+	if _, ok := ctx.Value("scopes").([]string); ok {
+		return false
+	}
+	if service.Policy == nil {
+		return false
+	}
+	_, role := actorFromContext(ctx)
+	return !service.Policy.Allowed(role, policy.AssignmentWriteAny)
+}
+
+// attachBookCounts sets BookCount on every category with a single aggregate query.
+func (service *CategoryServiceImpl) attachBookCounts(ctx context.Context, tx *sql.Tx, categories ...*params.CategoryResponse) error {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(categories))
+	for i, cate := range categories {
+		ids[i] = cate.ID
+	}
+
+	counts, err := service.CategoryRepository.CountBooksOfCategories(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, cate := range categories {
+		count := counts[cate.ID]
+		cate.BookCount = &count
+	}
+	return nil
+}
+
+// uniqueIDs drops zero and duplicate IDs and sorts the rest.
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]bool, len(ids))
+	unique := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique
+}
+
+func toCategoryResponse(cate *models.Category) *params.CategoryResponse {
+	return &params.CategoryResponse{
+		ID:          cate.ID,
+		Name:        cate.Name,
+		Description: cate.Description,
+		CreatedAt:   cate.CreatedAt,
+		UpdatedAt:   cate.UpdatedAt,
+	}
+}
+
+func toCategoryRevisionResponse(rev *models.CategoryRevision) *params.CategoryRevisionResponse {
+	return &params.CategoryRevisionResponse{
+		Revision:    rev.Revision,
+		CategoryID:  rev.CategoryID,
+		Name:        rev.Name,
+		Description: rev.Description,
+		ActorID:     rev.ActorID,
+		CreatedAt:   rev.CreatedAt,
+	}
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api-category/internal/grpc/client"
+	"library-api-category/internal/models"
+	"library-api-category/internal/params"
+	"library-api-category/internal/policy"
+	"library-api-category/internal/repositories"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type stubCategoryRepository struct {
+	repositories.CategoryRepository
+	err      error
+	assigned []*models.BookCategory
+	// books are the books assigned to every category
+	books   []uint64
+	deleted []uint64
+}
+
+func (repository *stubCategoryRepository) FindCategoryByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*models.Category, error) {
+	return &models.Category{ID: id, Name: "Fiction"}, nil
+}
+
+func (repository *stubCategoryRepository) RemoveBooksOfCategory(ctx context.Context, tx *sql.Tx, categoryID uint64) ([]uint64, error) {
+	return repository.books, repository.err
+}
+
+func (repository *stubCategoryRepository) DeleteCategory(ctx context.Context, tx *sql.Tx, id uint64) error {
+	if repository.err != nil {
+		return repository.err
+	}
+	repository.deleted = append(repository.deleted, id)
+	return nil
+}
+
+func (repository *stubCategoryRepository) CreateCategory(ctx context.Context, tx *sql.Tx, cate *models.Category) error {
+	cate.ID = 1
+	return repository.err
+}
+
+func (repository *stubCategoryRepository) AddBookCategory(ctx context.Context, tx *sql.Tx, bookCate *models.BookCategory) error {
+	if repository.err != nil {
+		return repository.err
+	}
+	repository.assigned = append(repository.assigned, bookCate)
+	return nil
+}
+
+type stubAuditLogRepository struct {
+	repositories.AuditLogRepository
+	err    error
+	audits []*models.AuditLog
+}
+
+func (repository *stubAuditLogRepository) CreateAuditLog(ctx context.Context, tx *sql.Tx, audit *models.AuditLog) error {
+	if repository.err != nil {
+		return repository.err
+	}
+	repository.audits = append(repository.audits, audit)
+	return nil
+}
+
+type stubRevisionRepository struct {
+	repositories.CategoryRevisionRepository
+	err       error
+	revisions []*models.CategoryRevision
+}
+
+func (repository *stubRevisionRepository) ListRevisions(ctx context.Context, tx *sql.Tx, categoryID uint64) ([]*models.CategoryRevision, error) {
+	return repository.revisions, repository.err
+}
+
+func (repository *stubRevisionRepository) CreateRevision(ctx context.Context, tx *sql.Tx, revision *models.CategoryRevision) error {
+	return repository.err
+}
+
+type stubOutboxRepository struct {
+	repositories.OutboxRepository
+	err    error
+	events []*models.OutboxEvent
+}
+
+func (repository *stubOutboxRepository) CreateEvent(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error {
+	if repository.err != nil {
+		return repository.err
+	}
+	repository.events = append(repository.events, event)
+	return nil
+}
+
+type testCategoryService struct {
+	*CategoryServiceImpl
+	mock       sqlmock.Sqlmock
+	categories *stubCategoryRepository
+	audits     *stubAuditLogRepository
+	revisions  *stubRevisionRepository
+	outbox     *stubOutboxRepository
+}
+
+func newTestCategoryService(t *testing.T) *testCategoryService {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	service := &testCategoryService{
+		mock:       mock,
+		categories: &stubCategoryRepository{},
+		audits:     &stubAuditLogRepository{},
+		revisions:  &stubRevisionRepository{},
+		outbox:     &stubOutboxRepository{},
+	}
+	service.CategoryServiceImpl = &CategoryServiceImpl{
+		DB:                         db,
+		CategoryRepository:         service.categories,
+		AuditLogRepository:         service.audits,
+		CategoryRevisionRepository: service.revisions,
+		OutboxRepository:           service.outbox,
+	}
+	return service
+}
+
+func TestCreateCategoryTransaction(t *testing.T) {
+	failure := errors.New("insert failed")
+
+	tests := []struct {
+		name      string
+		setup     func(service *testCategoryService)
+		wantError bool
+	}{
+		{
+			name: "commits the category with its audit entry and event",
+			setup: func(service *testCategoryService) {
+				service.mock.ExpectCommit()
+			},
+		},
+		{
+			name: "rolls back when the audit entry fails",
+			setup: func(service *testCategoryService) {
+				service.audits.err = failure
+				service.mock.ExpectRollback()
+			},
+			wantError: true,
+		},
+		{
+			name: "rolls back when the outbox event fails",
+			setup: func(service *testCategoryService) {
+				service.outbox.err = failure
+				service.mock.ExpectRollback()
+			},
+			wantError: true,
+		},
+		{
+			name: "rolls back when the revision fails",
+			setup: func(service *testCategoryService) {
+				service.revisions.err = failure
+				service.mock.ExpectRollback()
+			},
+			wantError: true,
+		},
+		{
+			name: "reports a failed commit",
+			setup: func(service *testCategoryService) {
+				service.mock.ExpectCommit().WillReturnError(sql.ErrConnDone)
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestCategoryService(t)
+			service.mock.ExpectBegin()
+			tt.setup(service)
+
+			custErr := service.CreateCategory(context.Background(), &params.CategoryRequest{Name: "Fiction"})
+			if (custErr != nil) != tt.wantError {
+				t.Fatalf("CreateCategory error = %v, want error %v", custErr, tt.wantError)
+			}
+			if custErr != nil && custErr.StatusCode != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", custErr.StatusCode)
+			}
+			if err := service.mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDeleteCategoryRemovesBooks(t *testing.T) {
+	service := newTestCategoryService(t)
+	service.categories.books = []uint64{3, 5}
+	service.mock.ExpectBegin()
+	service.mock.ExpectCommit()
+
+	custErr := service.DeleteCategory(context.Background(), 1)
+	if custErr != nil {
+		t.Fatalf("DeleteCategory: %v", custErr)
+	}
+	if err := service.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	wantEvents := []struct {
+		eventType   string
+		aggregateID uint64
+	}{
+		{models.EventBookCategoryRemoved, 3},
+		{models.EventBookCategoryRemoved, 5},
+		{models.EventCategoryDeleted, 1},
+	}
+	if len(service.outbox.events) != len(wantEvents) {
+		t.Fatalf("%d events, want %d", len(service.outbox.events), len(wantEvents))
+	}
+	for i, want := range wantEvents {
+		event := service.outbox.events[i]
+		if event.EventType != want.eventType || event.AggregateID != want.aggregateID {
+			t.Errorf("event %d = %s %d, want %s %d", i, event.EventType, event.AggregateID, want.eventType, want.aggregateID)
+		}
+	}
+
+	wantAudits := []string{models.AuditActionBookCategoryRemove, models.AuditActionBookCategoryRemove, models.AuditActionCategoryDelete}
+	if len(service.audits.audits) != len(wantAudits) {
+		t.Fatalf("%d audit entries, want %d", len(service.audits.audits), len(wantAudits))
+	}
+	for i, want := range wantAudits {
+		if action := service.audits.audits[i].Action; action != want {
+			t.Errorf("audit %d = %s, want %s", i, action, want)
+		}
+	}
+	if len(service.categories.deleted) != 1 {
+		t.Errorf("category deleted %d times, want once", len(service.categories.deleted))
+	}
+}
+
+func TestAddBookCategoryAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string
+		wantStatus int
+	}{
+		{
+			name:       "key without assignment:write:any is refused even for its own id as author",
+			scopes:     []string{policy.AssignmentWrite},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "key with assignment:write:any may assign any book",
+			scopes: []string{policy.AssignmentWrite, policy.AssignmentWriteAny},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestCategoryService(t)
+			// the book's author id equals the key id, which must not grant ownership
+			service.BookLookup = client.NewFakeBookLookup(&models.Book{ID: 10, AuthorID: 7})
+			service.Policy = mustPolicy(t)
+			if tt.wantStatus == 0 {
+				service.mock.ExpectBegin()
+				service.mock.ExpectCommit()
+			}
+
+			ctx := context.WithValue(context.Background(), "apiKeyId", uint64(7))
+			ctx = context.WithValue(ctx, "scopes", tt.scopes)
+
+			custErr := service.AddBookCategory(ctx, &params.BookCategoryRequest{BookID: 10, CategoryID: 1})
+			if tt.wantStatus == 0 {
+				if custErr != nil {
+					t.Fatalf("AddBookCategory: %v", custErr)
+				}
+				if len(service.categories.assigned) != 1 {
+					t.Errorf("%d assignments written, want 1", len(service.categories.assigned))
+				}
+			} else {
+				if custErr == nil || custErr.StatusCode != tt.wantStatus {
+					t.Fatalf("AddBookCategory error = %v, want status %d", custErr, tt.wantStatus)
+				}
+				if len(service.categories.assigned) != 0 {
+					t.Errorf("assignment written for a refused key")
+				}
+			}
+			if err := service.mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAddBookCategoryBookLookup(t *testing.T) {
+	lookupFailure := errors.New("book service unavailable")
+
+	tests := []struct {
+		name       string
+		role       string
+		strict     bool
+		lookupErr  error
+		bookID     uint64
+		wantStatus int
+	}{
+		{
+			name:   "known book",
+			role:   "admin",
+			bookID: 10,
+		},
+		{
+			name:       "unknown book",
+			role:       "admin",
+			bookID:     11,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:      "lookup failure assigns unverified",
+			role:      "admin",
+			lookupErr: lookupFailure,
+			bookID:    10,
+		},
+		{
+			name:       "strict lookup failure",
+			role:       "admin",
+			strict:     true,
+			lookupErr:  lookupFailure,
+			bookID:     10,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "strict unknown book",
+			role:       "admin",
+			strict:     true,
+			bookID:     11,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "author of the book",
+			role:   "author",
+			bookID: 10,
+		},
+		{
+			name:       "author of another book",
+			role:       "author",
+			bookID:     12,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "author whose ownership cannot be checked",
+			role:       "author",
+			lookupErr:  lookupFailure,
+			bookID:     10,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestCategoryService(t)
+			books := client.NewFakeBookLookup(
+				&models.Book{ID: 10, AuthorID: 3},
+				&models.Book{ID: 12, AuthorID: 4},
+			)
+			books.FailWith(tt.lookupErr)
+			service.BookLookup = books
+			service.StrictBookLookup = tt.strict
+			service.Policy = mustPolicy(t)
+			if tt.wantStatus == 0 {
+				service.mock.ExpectBegin()
+				service.mock.ExpectCommit()
+			}
+
+			ctx := context.WithValue(context.Background(), "authId", 3)
+			ctx = context.WithValue(ctx, "role", tt.role)
+
+			custErr := service.AddBookCategory(ctx, &params.BookCategoryRequest{BookID: tt.bookID, CategoryID: 1})
+			if tt.wantStatus == 0 {
+				if custErr != nil {
+					t.Fatalf("AddBookCategory: %v", custErr)
+				}
+				if len(service.categories.assigned) != 1 {
+					t.Errorf("%d assignments written, want 1", len(service.categories.assigned))
+				}
+			} else {
+				if custErr == nil || custErr.StatusCode != tt.wantStatus {
+					t.Fatalf("AddBookCategory error = %v, want status %d", custErr, tt.wantStatus)
+				}
+				if len(service.categories.assigned) != 0 {
+					t.Errorf("assignment written for a refused book")
+				}
+			}
+			if err := service.mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func mustPolicy(t *testing.T) *policy.Engine {
+	t.Helper()
+
+	engine, err := policy.NewEngine("")
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	return engine
+}
+
+func TestListCategoryRevisionsOfDeletedCategory(t *testing.T) {
+	service := newTestCategoryService(t)
+	service.revisions.revisions = []*models.CategoryRevision{
+		{CategoryID: 1, Revision: 1, Name: "Fiction"},
+		{CategoryID: 1, Revision: 2, Name: "Novels"},
+	}
+	service.mock.ExpectBegin()
+	service.mock.ExpectCommit()
+
+	revisions, custErr := service.ListCategoryRevisions(context.Background(), 1)
+	if custErr != nil {
+		t.Fatalf("ListCategoryRevisions: %v", custErr)
+	}
+	if len(revisions) != 2 || revisions[1].Name != "Novels" {
+		t.Errorf("revisions = %+v, want both revisions of the deleted category", revisions)
+	}
+}
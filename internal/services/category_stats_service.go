@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/models"
+	"library-api-category/internal/params"
+	"library-api-category/internal/repositories"
+	"time"
+)
+
+type CategoryStatsService interface {
+	GetCategoryStats(ctx context.Context, top int, days int) (*params.CategoryStatsResponse, *response.CustomError)
+	ReconcileBookCounts(ctx context.Context, dryRun bool) (*params.CountReconciliationResponse, *response.CustomError)
+}
+
+type CategoryStatsServiceImpl struct {
+	DB                      *sql.DB
+	CategoryStatsRepository repositories.CategoryStatsRepository
+}
+
+func NewCategoryStatsService(db *sql.DB, CategoryStatsRepository repositories.CategoryStatsRepository) CategoryStatsService {
+	return &CategoryStatsServiceImpl{
+		DB:                      db,
+		CategoryStatsRepository: CategoryStatsRepository,
+	}
+}
+
+// GetCategoryStats reads every aggregate from one repeatable read snapshot so
+// the totals and the lists agree with each other.
+func (service *CategoryStatsServiceImpl) GetCategoryStats(ctx context.Context, top int, days int) (_ *params.CategoryStatsResponse, custErr *response.CustomError) {
+	tx, err := service.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	totals, err := service.CategoryStatsRepository.GetTotals(ctx, tx)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch category statistics: " + err.Error())
+	}
+
+	empty, err := service.CategoryStatsRepository.ListEmptyCategories(ctx, tx, top)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch empty categories: " + err.Error())
+	}
+
+	mostUsed, err := service.CategoryStatsRepository.ListMostUsed(ctx, tx, top)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch most used categories: " + err.Error())
+	}
+
+	leastUsed, err := service.CategoryStatsRepository.ListLeastUsed(ctx, tx, top)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch least used categories: " + err.Error())
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	perDay, err := service.CategoryStatsRepository.CountAssignmentsPerDay(ctx, tx, since)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch assignments per day: " + err.Error())
+	}
+
+	stats := &params.CategoryStatsResponse{
+		TotalCategories:    totals.TotalCategories,
+		TotalAssignments:   totals.TotalAssignments,
+		CategorizedBooks:   totals.CategorizedBooks,
+		EmptyCategoryCount: totals.EmptyCategories,
+		EmptyCategories:    toCategoryUsageResponses(empty),
+		MostUsed:           toCategoryUsageResponses(mostUsed),
+		LeastUsed:          toCategoryUsageResponses(leastUsed),
+		AssignmentsPerDay:  make([]*params.DailyAssignmentsResponse, len(perDay)),
+	}
+	for i, day := range perDay {
+		stats.AssignmentsPerDay[i] = &params.DailyAssignmentsResponse{
+			Day:   day.Day,
+			Count: day.Count,
+		}
+	}
+
+	return stats, nil
+}
+
+// ReconcileBookCounts reports every category whose materialized book count
+// drifted from its assignments and, unless dryRun, recomputes all counts.
+func (service *CategoryStatsServiceImpl) ReconcileBookCounts(ctx context.Context, dryRun bool) (_ *params.CountReconciliationResponse, custErr *response.CustomError) {
+	tx, err := service.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: dryRun})
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	drifts, err := service.CategoryStatsRepository.FindCountDrift(ctx, tx)
+	if err != nil {
+		return nil, response.GeneralError("Failed to compare book counts: " + err.Error())
+	}
+
+	result := &params.CountReconciliationResponse{
+		DryRun: dryRun,
+		Drift:  make([]*params.CountDriftResponse, len(drifts)),
+	}
+	for i, drift := range drifts {
+		result.Drift[i] = &params.CountDriftResponse{
+			CategoryID:  drift.CategoryID,
+			StoredCount: drift.StoredCount,
+			ActualCount: drift.ActualCount,
+		}
+	}
+
+	if !dryRun && len(drifts) > 0 {
+		result.Fixed, err = service.CategoryStatsRepository.RecomputeBookCounts(ctx, tx)
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+	}
+
+	return result, nil
+}
+
+func toCategoryUsageResponses(usages []*models.CategoryUsage) []*params.CategoryUsageResponse {
+	usageResponses := make([]*params.CategoryUsageResponse, len(usages))
+	for i, usage := range usages {
+		usageResponses[i] = &params.CategoryUsageResponse{
+			CategoryID: usage.CategoryID,
+			Name:       usage.Name,
+			BookCount:  usage.BookCount,
+		}
+	}
+	return usageResponses
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"library-api-category/internal/commons/response"
+	"log"
+)
+
+// endTx finishes the transaction of a service call. It commits only when the
+// call returned no error and rolls back when it returned one or panicked, so a
+// change is never written without its audit entry, revision and outbox event.
+// A failed commit becomes the call's error. It must be deferred directly for
+// recover to see the panic.
+func endTx(tx *sql.Tx, custErr **response.CustomError) {
+	if p := recover(); p != nil {
+		tx.Rollback()
+		log.Printf("transaction rolled back after panic: %v", p)
+		*custErr = response.GeneralError(fmt.Sprintf("Unexpected error: %v", p))
+		return
+	}
+
+	if *custErr != nil {
+		tx.Rollback()
+		return
+	}
+
+	err := tx.Commit()
+	if err != nil {
+		*custErr = response.GeneralError("Failed to commit the transaction: " + err.Error())
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"library-api-category/internal/commons/response"
+	"library-api-category/internal/models"
+	"library-api-category/internal/params"
+	"library-api-category/internal/repositories"
+	"net/url"
+	"time"
+)
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req *params.WebhookSubscriptionRequest) (*params.WebhookSubscriptionResponse, *response.CustomError)
+	GetAllSubscriptions(ctx context.Context) ([]*params.WebhookSubscriptionResponse, *response.CustomError)
+	GetDetailSubscription(ctx context.Context, id uint64) (*params.WebhookSubscriptionResponse, *response.CustomError)
+	UpdateSubscription(ctx context.Context, id uint64, req *params.WebhookSubscriptionRequest) *response.CustomError
+	DeleteSubscription(ctx context.Context, id uint64) *response.CustomError
+	GetAllDeliveries(ctx context.Context, filter *models.WebhookDeliveryFilter, pagination *models.Pagination) ([]*params.WebhookDeliveryResponse, *response.CustomError)
+	RedeliverDelivery(ctx context.Context, id uint64) *response.CustomError
+}
+
+type WebhookServiceImpl struct {
+	DB                *sql.DB
+	WebhookRepository repositories.WebhookRepository
+}
+
+func NewWebhookService(db *sql.DB, WebhookRepository repositories.WebhookRepository) WebhookService {
+	return &WebhookServiceImpl{
+		DB:                db,
+		WebhookRepository: WebhookRepository,
+	}
+}
+
+var webhookEventTypes = map[string]bool{
+	models.EventCategoryCreated:      true,
+	models.EventCategoryUpdated:      true,
+	models.EventCategoryDeleted:      true,
+	models.EventBookCategoryAssigned: true,
+	models.EventBookCategoryRemoved:  true,
+}
+
+func (service *WebhookServiceImpl) CreateSubscription(ctx context.Context, req *params.WebhookSubscriptionRequest) (_ *params.WebhookSubscriptionResponse, custErr *response.CustomError) {
+	custErr = validateWebhookRequest(req)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		_, err := rand.Read(buf)
+		if err != nil {
+			return nil, response.GeneralError("Failed to generate webhook secret: " + err.Error())
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed Connection to database errors: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	sub := models.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		Active:     req.Active == nil || *req.Active,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+
+	err = service.WebhookRepository.CreateSubscription(ctx, tx, &sub)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	// the secret is only returned once, when the subscription is created
+	subResponse := toWebhookSubscriptionResponse(&sub)
+	subResponse.Secret = sub.Secret
+
+	return subResponse, nil
+}
+
+func (service *WebhookServiceImpl) GetAllSubscriptions(ctx context.Context) (_ []*params.WebhookSubscriptionResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	subs, err := service.WebhookRepository.GetAllSubscriptions(ctx, tx)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch webhook subscriptions: " + err.Error())
+	}
+
+	subResponses := make([]*params.WebhookSubscriptionResponse, len(subs))
+	for i, sub := range subs {
+		subResponses[i] = toWebhookSubscriptionResponse(sub)
+	}
+
+	return subResponses, nil
+}
+
+func (service *WebhookServiceImpl) GetDetailSubscription(ctx context.Context, id uint64) (_ *params.WebhookSubscriptionResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	sub, err := service.WebhookRepository.FindSubscriptionByID(ctx, tx, id)
+	if err != nil {
+		return nil, response.NotFoundError("Webhook subscription not found")
+	}
+
+	return toWebhookSubscriptionResponse(sub), nil
+}
+
+func (service *WebhookServiceImpl) UpdateSubscription(ctx context.Context, id uint64, req *params.WebhookSubscriptionRequest) (custErr *response.CustomError) {
+	custErr = validateWebhookRequest(req)
+	if custErr != nil {
+		return custErr
+	}
+
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	sub, err := service.WebhookRepository.FindSubscriptionByID(ctx, tx, id)
+	if err != nil {
+		return response.NotFoundError("Webhook subscription not found")
+	}
+
+	sub.URL = req.URL
+	sub.EventTypes = req.EventTypes
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	sub.UpdatedAt = time.Now()
+
+	err = service.WebhookRepository.UpdateSubscription(ctx, tx, sub)
+	if err != nil {
+		return response.GeneralError("Failed to update webhook subscription: " + err.Error())
+	}
+
+	return nil
+}
+
+func (service *WebhookServiceImpl) DeleteSubscription(ctx context.Context, id uint64) (custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	_, err = service.WebhookRepository.FindSubscriptionByID(ctx, tx, id)
+	if err != nil {
+		return response.NotFoundError("Webhook subscription not found")
+	}
+
+	err = service.WebhookRepository.DeleteSubscription(ctx, tx, id)
+	if err != nil {
+		return response.GeneralError("Failed to delete webhook subscription: " + err.Error())
+	}
+
+	return nil
+}
+
+func (service *WebhookServiceImpl) GetAllDeliveries(ctx context.Context, filter *models.WebhookDeliveryFilter, pagination *models.Pagination) (_ []*params.WebhookDeliveryResponse, custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return nil, response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	if filter.SubscriptionID != 0 {
+		_, err = service.WebhookRepository.FindSubscriptionByID(ctx, tx, filter.SubscriptionID)
+		if err != nil {
+			return nil, response.NotFoundError("Webhook subscription not found")
+		}
+	}
+
+	pagination.Offset = (pagination.Page - 1) * pagination.PageSize
+
+	deliveries, err := service.WebhookRepository.GetAllDeliveries(ctx, tx, filter, pagination)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch webhook deliveries: " + err.Error())
+	}
+
+	deliveryResponses := make([]*params.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryResponses[i] = &params.WebhookDeliveryResponse{
+			ID:             delivery.ID,
+			SubscriptionID: delivery.SubscriptionID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+		}
+	}
+
+	pagination.PageCount = (pagination.TotalCount + pagination.PageSize - 1) / pagination.PageSize
+
+	return deliveryResponses, nil
+}
+
+func (service *WebhookServiceImpl) RedeliverDelivery(ctx context.Context, id uint64) (custErr *response.CustomError) {
+	tx, err := service.DB.Begin()
+	if err != nil {
+		return response.GeneralError("Failed to connect to the database: " + err.Error())
+	}
+	defer endTx(tx, &custErr)
+
+	err = service.WebhookRepository.RedeliverDelivery(ctx, tx, id)
+	if err != nil {
+		return response.NotFoundError("Webhook delivery not found")
+	}
+
+	return nil
+}
+
+func validateWebhookRequest(req *params.WebhookSubscriptionRequest) *response.CustomError {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return response.BadRequestError("url must be an absolute http or https URL")
+	}
+
+	for _, eventType := range req.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return response.BadRequestError("unknown event type " + eventType)
+		}
+	}
+
+	return nil
+}
+
+func toWebhookSubscriptionResponse(sub *models.WebhookSubscription) *params.WebhookSubscriptionResponse {
+	return &params.WebhookSubscriptionResponse{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+		UpdatedAt:  sub.UpdatedAt,
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS category_revisions;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE category_revisions (
+    id BIGSERIAL PRIMARY KEY NOT NULL,
+    category_id INT NOT NULL,
+    revision INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    actor_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (category_id, revision),
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
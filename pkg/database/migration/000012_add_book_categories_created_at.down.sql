@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_book_categories_created_at;
+DROP INDEX IF EXISTS idx_book_categories_book_id;
+DROP INDEX IF EXISTS idx_book_categories_category_id;
+
+ALTER TABLE book_categories DROP COLUMN IF EXISTS created_at;
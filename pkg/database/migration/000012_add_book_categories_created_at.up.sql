@@ -0,0 +1,5 @@
+ALTER TABLE book_categories ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_book_categories_category_id ON book_categories (category_id);
+CREATE INDEX idx_book_categories_book_id ON book_categories (book_id);
+CREATE INDEX idx_book_categories_created_at ON book_categories (created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE category_book_counts (
+    category_id INT PRIMARY KEY NOT NULL,
+    book_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_category_book_counts_book_count ON category_book_counts (book_count);
+
+INSERT INTO category_book_counts (category_id, book_count)
+SELECT c.id, COUNT(bc.book_id)
+FROM categories c
+LEFT JOIN book_categories bc ON bc.category_id = c.id
+GROUP BY c.id;
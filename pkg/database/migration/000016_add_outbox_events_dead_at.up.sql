@@ -0,0 +1,5 @@
+ALTER TABLE outbox_events ADD COLUMN dead_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_pending ON outbox_events (id) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX idx_outbox_events_dead ON outbox_events (id) WHERE dead_at IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE category_revisions
+    ADD CONSTRAINT category_revisions_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE NOT VALID;
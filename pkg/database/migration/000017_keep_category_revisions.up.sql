@@ -0,0 +1 @@
+ALTER TABLE category_revisions DROP CONSTRAINT IF EXISTS category_revisions_category_id_fkey;
@@ -10,18 +10,7 @@ import (
 )
 
 func NewPqSQLClient() (*sql.DB, error) {
-	var (
-		DB_User   = config.ENV.DBUserName
-		DB_Pass   = config.ENV.DBUserPassword
-		DB_Host   = config.ENV.DBHost
-		DB_Port   = config.ENV.DBPort
-		DB_DbName = config.ENV.DBName
-	)
-	dsn := fmt.Sprintf(
-		"postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		DB_User, DB_Pass, DB_Host, DB_Port, DB_DbName,
-	)
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open("postgres", DSN())
 	if err != nil {
 		return nil, err
 	}
@@ -36,3 +25,18 @@ func NewPqSQLClient() (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// DSN returns the connection string built from config, also used by LISTEN connections.
+func DSN() string {
+	var (
+		DB_User   = config.ENV.DBUserName
+		DB_Pass   = config.ENV.DBUserPassword
+		DB_Host   = config.ENV.DBHost
+		DB_Port   = config.ENV.DBPort
+		DB_DbName = config.ENV.DBName
+	)
+	return fmt.Sprintf(
+		"postgresql://%s:%s@%s:%s/%s?sslmode=disable",
+		DB_User, DB_Pass, DB_Host, DB_Port, DB_DbName,
+	)
+}
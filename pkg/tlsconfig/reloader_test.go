@@ -0,0 +1,191 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue returns a server certificate signed by the CA for the given DNS names
+// and IP addresses.
+func (ca *testCA) issue(t *testing.T, dnsNames []string, ips []net.IP) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake dials a server presenting cert with config and returns the
+// client's handshake error.
+func handshake(t *testing.T, config *tls.Config, cert tls.Certificate) error {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.(*tls.Conn).Handshake()
+		conn.Close()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), config)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func TestClientConfigVerifiesHost(t *testing.T) {
+	ca := newTestCA(t)
+	reloader, err := NewReloader(Files{CA: ca.file})
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		serverName string
+		target     string
+		dnsNames   []string
+		ips        []net.IP
+		wantErr    bool
+	}{
+		{
+			name:       "server name matches",
+			serverName: "auth.internal",
+			target:     "10.0.0.1:50052",
+			dnsNames:   []string{"auth.internal"},
+		},
+		{
+			name:       "certificate for another host",
+			serverName: "auth.internal",
+			target:     "10.0.0.1:50052",
+			dnsNames:   []string{"evil.internal"},
+			wantErr:    true,
+		},
+		{
+			name:     "target host matches",
+			target:   "dns:///auth.internal:50052",
+			dnsNames: []string{"auth.internal"},
+		},
+		{
+			name:   "IP target matches an IP SAN",
+			target: "34.142.158.122:50052",
+			ips:    []net.IP{net.ParseIP("34.142.158.122")},
+		},
+		{
+			name:     "IP target against a certificate for another IP",
+			target:   "34.142.158.122:50052",
+			dnsNames: []string{"auth.internal"},
+			ips:      []net.IP{net.ParseIP("10.0.0.1")},
+			wantErr:  true,
+		},
+		{
+			name:   "IPv6 target",
+			target: "[::1]:50052",
+			ips:    []net.IP{net.IPv6loopback},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := reloader.ClientConfig(tt.serverName, tt.target)
+			if err != nil {
+				t.Fatalf("ClientConfig: %v", err)
+			}
+
+			err = handshake(t, config, ca.issue(t, tt.dnsNames, tt.ips))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handshake error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientConfigWithoutHost(t *testing.T) {
+	ca := newTestCA(t)
+	reloader, err := NewReloader(Files{CA: ca.file})
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+
+	for _, target := range []string{"", ":50052"} {
+		_, err := reloader.ClientConfig("", target)
+		if err == nil {
+			t.Errorf("ClientConfig(%q) succeeded without a host to verify", target)
+		}
+	}
+}
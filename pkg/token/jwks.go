@@ -0,0 +1,252 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minJWKSRefresh limits how often an unknown key ID can force a reload, so
+// tokens with made up kids cannot hammer the JWKS endpoint.
+const minJWKSRefresh = 30 * time.Second
+
+// JWKS holds the public keys of a JSON Web Key Set read from a file or URL.
+// Keys are reloaded every refresh interval and whenever a token names a key
+// ID that is not known yet, which picks up rotated keys without a restart.
+// A failed reload keeps the previous keys.
+type JWKS struct {
+	path    string
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	group       singleflight.Group
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	lastAttempt time.Time
+	loadedAt    time.Time
+}
+
+// NewJWKS loads a key set from source, a file path or an http(s) URL.
+func NewJWKS(source string, refresh time.Duration) (*JWKS, error) {
+	jwks := &JWKS{
+		refresh: refresh,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		keys:    map[string]crypto.PublicKey{},
+	}
+	if isURL(source) {
+		jwks.url = source
+	} else {
+		jwks.path = source
+	}
+
+	// An unreachable URL is retried on first use instead of failing startup,
+	// it is usually served by the same user service this mode stands in for.
+	err := jwks.reload()
+	if err != nil && jwks.path != "" {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("jwks: %v", err)
+	}
+	return jwks, nil
+}
+
+// Key returns the key for kid. An empty kid matches when the set has exactly
+// one key.
+func (jwks *JWKS) Key(kid string) (crypto.PublicKey, bool) {
+	jwks.mu.Lock()
+	stale := jwks.refresh > 0 && time.Since(jwks.loadedAt) > jwks.refresh
+	key, ok := jwks.lookup(kid)
+	due := (stale || !ok) && time.Since(jwks.lastAttempt) > minJWKSRefresh
+	jwks.mu.Unlock()
+	if !due {
+		return key, ok
+	}
+
+	// concurrent callers share one fetch, and lookups that need no reload
+	// are not held up by it
+	_, err, _ := jwks.group.Do("reload", func() (interface{}, error) {
+		return nil, jwks.reload()
+	})
+	if err != nil {
+		log.Printf("jwks: %v", err)
+	}
+
+	jwks.mu.Lock()
+	defer jwks.mu.Unlock()
+	return jwks.lookup(kid)
+}
+
+func (jwks *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(jwks.keys) == 1 {
+		for _, key := range jwks.keys {
+			return key, true
+		}
+	}
+	key, ok := jwks.keys[kid]
+	return key, ok
+}
+
+// reload fetches the key set without holding mu and swaps it in once parsed.
+func (jwks *JWKS) reload() error {
+	jwks.mu.Lock()
+	jwks.lastAttempt = time.Now()
+	jwks.mu.Unlock()
+
+	data, err := jwks.fetch()
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	jwks.mu.Lock()
+	jwks.keys = keys
+	jwks.loadedAt = time.Now()
+	jwks.mu.Unlock()
+	return nil
+}
+
+func (jwks *JWKS) fetch() ([]byte, error) {
+	if jwks.path != "" {
+		return os.ReadFile(jwks.path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwks.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwks.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := jwks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %d", jwks.url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS reads the RSA and EC signing keys of a key set, other keys are
+// skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err := json.Unmarshal(data, &set)
+	if err != nil {
+		return nil, fmt.Errorf("parsing jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		switch jwk.Kty {
+		case "RSA":
+			key, err = rsaKey(jwk)
+		case "EC":
+			key, err = ecKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing jwk %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("rsa exponent is too large")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	_, err = key.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
@@ -0,0 +1,215 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// encodeJWKS writes keys, keyed by kid, as a JSON Web Key Set.
+func encodeJWKS(t *testing.T, keys map[string]interface{}) []byte {
+	t.Helper()
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jsonWebKey{Kty: "RSA", Kid: kid, Use: "sig", N: encode(key.N), E: encode(big.NewInt(int64(key.E)))})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, jsonWebKey{Kty: "EC", Kid: kid, Crv: key.Curve.Params().Name, X: encode(key.X), Y: encode(key.Y)})
+		default:
+			t.Fatalf("unsupported key %T", key)
+		}
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// newTestJWKS loads keys from a file.
+func newTestJWKS(t *testing.T, keys map[string]interface{}) *JWKS {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err := os.WriteFile(path, encodeJWKS(t, keys), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err := NewJWKS(path, 0)
+	if err != nil {
+		t.Fatalf("NewJWKS: %v", err)
+	}
+	return jwks
+}
+
+// jwksServer serves a key set that can be swapped and counts its fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	body    []byte
+	status  int
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, body []byte) *jwksServer {
+	t.Helper()
+
+	server := &jwksServer{body: body, status: http.StatusOK}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.fetches.Add(1)
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		w.WriteHeader(server.status)
+		w.Write(server.body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (server *jwksServer) serve(status int, body []byte) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.status = status
+	server.body = body
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// allowReload lets the next unknown kid reload the key set, as if
+// minJWKSRefresh had passed.
+func allowReload(jwks *JWKS) {
+	jwks.mu.Lock()
+	defer jwks.mu.Unlock()
+
+	jwks.lastAttempt = time.Time{}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t), newRSAKey(t)
+	server := newJWKSServer(t, encodeJWKS(t, map[string]interface{}{"old": &oldKey.PublicKey}))
+
+	jwks, err := NewJWKS(server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier("", jwks, "", "")
+	oldToken := sign(t, jwt.SigningMethodRS256, oldKey, "old", claims(nil))
+	newToken := sign(t, jwt.SigningMethodRS256, newKey, "new", claims(nil))
+
+	if _, err := verifier.Verify(oldToken); err != nil {
+		t.Fatalf("old key: %v", err)
+	}
+
+	server.serve(http.StatusOK, encodeJWKS(t, map[string]interface{}{"old": &oldKey.PublicKey, "new": &newKey.PublicKey}))
+
+	// a reload was just attempted, so an unknown kid does not trigger another
+	if _, err := verifier.Verify(newToken); err == nil {
+		t.Fatal("new key accepted before the key set could be reloaded")
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+
+	allowReload(jwks)
+	if _, err := verifier.Verify(newToken); err != nil {
+		t.Fatalf("new key after rotation: %v", err)
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	// known kids never reload
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(oldToken); err != nil {
+			t.Fatalf("old key after rotation: %v", err)
+		}
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	server.serve(http.StatusOK, encodeJWKS(t, map[string]interface{}{"new": &newKey.PublicKey}))
+	allowReload(jwks)
+	if _, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, oldKey, "retired", claims(nil))); err == nil {
+		t.Fatal("token with an unknown kid accepted")
+	}
+	if _, err := verifier.Verify(oldToken); err == nil {
+		t.Error("retired key still accepted after the key set dropped it")
+	}
+}
+
+func TestJWKSFailedReloadKeepsKeys(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, encodeJWKS(t, map[string]interface{}{"current": &key.PublicKey}))
+
+	jwks, err := NewJWKS(server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier("", jwks, "", "")
+
+	for _, failure := range []struct {
+		status int
+		body   []byte
+	}{
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusOK, body: []byte(`{"keys": []}`)},
+		{status: http.StatusOK, body: []byte(`not json`)},
+	} {
+		server.serve(failure.status, failure.body)
+		allowReload(jwks)
+
+		_, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, key, "unknown", claims(nil)))
+		if err == nil {
+			t.Fatal("token with an unknown kid accepted")
+		}
+		if _, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, key, "current", claims(nil))); err != nil {
+			t.Errorf("key lost after a failed reload (status %d): %v", failure.status, err)
+		}
+	}
+}
+
+func TestJWKSSingleKeyWithoutKid(t *testing.T) {
+	key := newRSAKey(t)
+	jwks := newTestJWKS(t, map[string]interface{}{"only": &key.PublicKey})
+
+	if _, ok := jwks.Key(""); !ok {
+		t.Error("empty kid does not match the only key")
+	}
+}
+
+func TestNewJWKSMissingFile(t *testing.T) {
+	_, err := NewJWKS(filepath.Join(t.TempDir(), "missing.json"), 0)
+	if err == nil {
+		t.Error("NewJWKS succeeded without a key set file")
+	}
+}
@@ -0,0 +1,230 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "secret"
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	return signed
+}
+
+func claims(extra jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"sub":  "1",
+		"role": "user",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestVerifierVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := newTestJWKS(t, map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+
+	tests := []struct {
+		name       string
+		issuer     string
+		audience   string
+		token      string
+		wantAuthID int
+		wantNoKey  bool
+		wantErr    bool
+	}{
+		{
+			name:       "HS256",
+			token:      sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(nil)),
+			wantAuthID: 1,
+		},
+		{
+			name:       "RS256 from the JWKS",
+			token:      sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(nil)),
+			wantAuthID: 1,
+		},
+		{
+			name:       "ES256 from the JWKS",
+			token:      sign(t, jwt.SigningMethodES256, ecKey, "ec", claims(nil)),
+			wantAuthID: 1,
+		},
+		{
+			name: "payload claim",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{
+				"payload": Token{AuthId: 5, Role: "admin", Expired: time.Now().Add(time.Hour)},
+			}),
+			wantAuthID: 5,
+		},
+		{
+			name:    "HS256 with another secret",
+			token:   sign(t, jwt.SigningMethodHS256, []byte("other"), "", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "RS256 signed by another key",
+			token:   sign(t, jwt.SigningMethodRS256, otherRSAKey, "rsa", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "key of the wrong type for the algorithm",
+			token:   sign(t, jwt.SigningMethodES256, ecKey, "rsa", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "unsigned",
+			token:   sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:      "unknown key id",
+			token:     sign(t, jwt.SigningMethodRS256, otherRSAKey, "unknown", claims(nil)),
+			wantNoKey: true,
+		},
+		{
+			name:    "expired",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+			wantErr: true,
+		},
+		{
+			name: "expired payload claim",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{
+				"payload": Token{AuthId: 5, Expired: time.Now().Add(-time.Minute)},
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "no expiry",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"sub": "1"}),
+			wantErr: true,
+		},
+		{
+			name:    "subject is not a user id",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"sub": "alice"})),
+			wantErr: true,
+		},
+		{
+			name:       "matching issuer",
+			issuer:     "user-service",
+			token:      sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"iss": "user-service"})),
+			wantAuthID: 1,
+		},
+		{
+			name:    "wrong issuer",
+			issuer:  "user-service",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"iss": "elsewhere"})),
+			wantErr: true,
+		},
+		{
+			name:    "missing issuer",
+			issuer:  "user-service",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:       "matching audience",
+			audience:   "library-api-category",
+			token:      sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"aud": []string{"library-api-book", "library-api-category"}})),
+			wantAuthID: 1,
+		},
+		{
+			name:     "wrong audience",
+			audience: "library-api-category",
+			token:    sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"aud": "library-api-book"})),
+			wantErr:  true,
+		},
+		{
+			name:     "missing audience",
+			audience: "library-api-category",
+			token:    sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(nil)),
+			wantErr:  true,
+		},
+		{
+			name:       "issuer and audience not checked when unset",
+			token:      sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(jwt.MapClaims{"iss": "elsewhere", "aud": "library-api-book"})),
+			wantAuthID: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(testSecret, jwks, tt.issuer, tt.audience)
+
+			payload, err := verifier.Verify(tt.token)
+			switch {
+			case tt.wantNoKey:
+				if !errors.Is(err, ErrNoVerificationKey) {
+					t.Errorf("err = %v, want ErrNoVerificationKey", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrNoVerificationKey) {
+					t.Errorf("err = %v, want the token rejected", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if payload.AuthId != tt.wantAuthID {
+					t.Errorf("AuthId = %d, want %d", payload.AuthId, tt.wantAuthID)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifierWithoutKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier("", nil, "", "")
+
+	for name, token := range map[string]string{
+		"HS256": sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(nil)),
+		"RS256": sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(nil)),
+	} {
+		if _, err := verifier.Verify(token); !errors.Is(err, ErrNoVerificationKey) {
+			t.Errorf("%s: err = %v, want ErrNoVerificationKey", name, err)
+		}
+	}
+}
+
+func TestVerifierValidateTokenWrapsErrors(t *testing.T) {
+	verifier := NewVerifier(testSecret, nil, "", "")
+
+	_, err := verifier.ValidateToken(context.Background(), sign(t, jwt.SigningMethodHS256, []byte("other"), "", claims(nil)))
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -19,7 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CategoryService_ListBookCategories_FullMethodName = "/category.CategoryService/ListBookCategories"
+	CategoryService_ListBookCategories_FullMethodName      = "/category.CategoryService/ListBookCategories"
+	CategoryService_BatchListBookCategories_FullMethodName = "/category.CategoryService/BatchListBookCategories"
+	CategoryService_BatchGetCategories_FullMethodName      = "/category.CategoryService/BatchGetCategories"
+	CategoryService_WatchCategories_FullMethodName         = "/category.CategoryService/WatchCategories"
 )
 
 // CategoryServiceClient is the client API for CategoryService service.
@@ -27,6 +30,13 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CategoryServiceClient interface {
 	ListBookCategories(ctx context.Context, in *BookCategoriesRequest, opts ...grpc.CallOption) (*BookCategoriesResponse, error)
+	// BatchListBookCategories returns the categories of up to 100 books in one call.
+	BatchListBookCategories(ctx context.Context, in *BatchBookCategoriesRequest, opts ...grpc.CallOption) (*BatchBookCategoriesResponse, error)
+	// BatchGetCategories returns up to 100 categories by id and the ids not found.
+	BatchGetCategories(ctx context.Context, in *BatchGetCategoriesRequest, opts ...grpc.CallOption) (*BatchGetCategoriesResponse, error)
+	// WatchCategories streams category and assignment changes. Resume after a
+	// disconnect by sending the revision of the last event received.
+	WatchCategories(ctx context.Context, in *WatchCategoriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CategoryEvent], error)
 }
 
 type categoryServiceClient struct {
@@ -47,11 +57,57 @@ func (c *categoryServiceClient) ListBookCategories(ctx context.Context, in *Book
 	return out, nil
 }
 
+func (c *categoryServiceClient) BatchListBookCategories(ctx context.Context, in *BatchBookCategoriesRequest, opts ...grpc.CallOption) (*BatchBookCategoriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchBookCategoriesResponse)
+	err := c.cc.Invoke(ctx, CategoryService_BatchListBookCategories_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) BatchGetCategories(ctx context.Context, in *BatchGetCategoriesRequest, opts ...grpc.CallOption) (*BatchGetCategoriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetCategoriesResponse)
+	err := c.cc.Invoke(ctx, CategoryService_BatchGetCategories_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) WatchCategories(ctx context.Context, in *WatchCategoriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CategoryEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CategoryService_ServiceDesc.Streams[0], CategoryService_WatchCategories_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCategoriesRequest, CategoryEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CategoryService_WatchCategoriesClient = grpc.ServerStreamingClient[CategoryEvent]
+
 // CategoryServiceServer is the server API for CategoryService service.
 // All implementations must embed UnimplementedCategoryServiceServer
 // for forward compatibility.
 type CategoryServiceServer interface {
 	ListBookCategories(context.Context, *BookCategoriesRequest) (*BookCategoriesResponse, error)
+	// BatchListBookCategories returns the categories of up to 100 books in one call.
+	BatchListBookCategories(context.Context, *BatchBookCategoriesRequest) (*BatchBookCategoriesResponse, error)
+	// BatchGetCategories returns up to 100 categories by id and the ids not found.
+	BatchGetCategories(context.Context, *BatchGetCategoriesRequest) (*BatchGetCategoriesResponse, error)
+	// WatchCategories streams category and assignment changes. Resume after a
+	// disconnect by sending the revision of the last event received.
+	WatchCategories(*WatchCategoriesRequest, grpc.ServerStreamingServer[CategoryEvent]) error
 	mustEmbedUnimplementedCategoryServiceServer()
 }
 
@@ -65,6 +121,15 @@ type UnimplementedCategoryServiceServer struct{}
 func (UnimplementedCategoryServiceServer) ListBookCategories(context.Context, *BookCategoriesRequest) (*BookCategoriesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListBookCategories not implemented")
 }
+func (UnimplementedCategoryServiceServer) BatchListBookCategories(context.Context, *BatchBookCategoriesRequest) (*BatchBookCategoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchListBookCategories not implemented")
+}
+func (UnimplementedCategoryServiceServer) BatchGetCategories(context.Context, *BatchGetCategoriesRequest) (*BatchGetCategoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetCategories not implemented")
+}
+func (UnimplementedCategoryServiceServer) WatchCategories(*WatchCategoriesRequest, grpc.ServerStreamingServer[CategoryEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCategories not implemented")
+}
 func (UnimplementedCategoryServiceServer) mustEmbedUnimplementedCategoryServiceServer() {}
 func (UnimplementedCategoryServiceServer) testEmbeddedByValue()                         {}
 
@@ -104,6 +169,53 @@ func _CategoryService_ListBookCategories_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CategoryService_BatchListBookCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchBookCategoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).BatchListBookCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_BatchListBookCategories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).BatchListBookCategories(ctx, req.(*BatchBookCategoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_BatchGetCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetCategoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).BatchGetCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_BatchGetCategories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).BatchGetCategories(ctx, req.(*BatchGetCategoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_WatchCategories_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCategoriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CategoryServiceServer).WatchCategories(m, &grpc.GenericServerStream[WatchCategoriesRequest, CategoryEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CategoryService_WatchCategoriesServer = grpc.ServerStreamingServer[CategoryEvent]
+
 // CategoryService_ServiceDesc is the grpc.ServiceDesc for CategoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +227,21 @@ var CategoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListBookCategories",
 			Handler:    _CategoryService_ListBookCategories_Handler,
 		},
+		{
+			MethodName: "BatchListBookCategories",
+			Handler:    _CategoryService_BatchListBookCategories_Handler,
+		},
+		{
+			MethodName: "BatchGetCategories",
+			Handler:    _CategoryService_BatchGetCategories_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCategories",
+			Handler:       _CategoryService_WatchCategories_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/category/category.proto",
 }